@@ -1,9 +1,13 @@
 package collargo
 
 import (
-	"github.com/satori/go.uuid"
+	"errors"
+	"fmt"
+	"hash/fnv"
 	"log"
+	"math"
 	// "reflect"
+	"strings"
 	"sync"
 	"time"
 )
@@ -86,29 +90,85 @@ func handleNode(node Node) elemType {
 	}
 }
 
-func handleEdge(upstream Node, downstream Node) elemType {
+// operatorTypes get the types and the config schemas of the registered operators
+func operatorTypes() map[string]interface{} {
+	types := []map[string]interface{}{}
+	for _, nodeType := range Operators.Types() {
+		operator, _ := Operators.Get(nodeType)
+		types = append(types, map[string]interface{}{
+			"type":   nodeType,
+			"schema": operator.Schema,
+		})
+	}
+	return map[string]interface{}{
+		"types": types,
+	}
+}
+
+func handleEdge(edge *Edge) elemType {
 	return elemType{
 		Group: "edges",
 		Data: elemData{
-			ID:     uuid.NewV4().String(),
-			Source: upstream.ID(),
-			Target: downstream.ID(),
+			ID:     edge.ID,
+			Label:  edge.Label,
+			Tags:   edge.Tags,
+			Source: edge.Source.ID(),
+			Target: edge.Target.ID(),
 		},
 	}
 }
 
+// nodeStats the signal statistics of a node
+type nodeStats struct {
+	Received int64 `json:"received"`
+	Sent     int64 `json:"sent"`
+	Errors   int64 `json:"errors"`
+	LastTime int64 `json:"lastTime"`
+}
+
 /**
  * Addon
  */
 
+// DevToolOptions the options of the devtool addon
+type DevToolOptions struct {
+	// SampleRate the ratio (0, 1] of signals recorded, a signal is either sampled on all nodes or on none,
+	// 0 means record all signals
+	SampleRate float64
+	// MaxBuffer the max number of buffered signals between two flushes, 0 means unbounded
+	MaxBuffer int
+	// FlushInterval the interval to push buffered elements and signals to the dev server
+	FlushInterval time.Duration
+	// Namespaces only record signals of nodes in these namespaces (or their sub namespaces), empty for all
+	Namespaces []string
+	// Tags only record signals of nodes having at least one of these tags, empty for all
+	Tags []string
+}
+
+// DefaultDevToolOptions the default options of the devtool addon
+func DefaultDevToolOptions() DevToolOptions {
+	return DevToolOptions{
+		SampleRate:    1,
+		MaxBuffer:     10000,
+		FlushInterval: 1 * time.Second,
+		Namespaces:    []string{},
+		Tags:          []string{},
+	}
+}
+
 // DevToolAddon the devtool addon
 type DevToolAddon struct {
 	sync.RWMutex
 	observers []Observer
 	elements  []elemType
+	removed   []elemType
 	signals   []signalType
 
+	options DevToolOptions
+	dropped int64
+
 	nodes map[string]Node
+	stats map[string]*nodeStats
 
 	client *WebsocketClient
 
@@ -116,10 +176,17 @@ type DevToolAddon struct {
 }
 
 // Observers get the observers of the devtool addon
-func (addon DevToolAddon) Observers() []Observer {
+func (addon *DevToolAddon) Observers() []Observer {
 	return addon.observers
 }
 
+// Dropped get the number of signals dropped because the buffer was full since the last flush
+func (addon *DevToolAddon) Dropped() int64 {
+	addon.RLock()
+	defer addon.RUnlock()
+	return addon.dropped
+}
+
 // Stop stop the addon
 func (addon *DevToolAddon) Stop() {
 	addon.command <- "quit"
@@ -136,10 +203,12 @@ func (addon *DevToolAddon) Run() {
 	addon.client.Emit("new model", map[string]string{
 		"process": "__anonymous__",
 	})
+	addon.client.Emit("operator types", operatorTypes())
 
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := time.NewTicker(addon.options.FlushInterval)
 
 	go func() {
+		defer ticker.Stop()
 		for {
 			select {
 			case _ = <-ticker.C:
@@ -151,7 +220,7 @@ func (addon *DevToolAddon) Run() {
 			case c := <-addon.command:
 				switch c {
 				case "quit":
-					break
+					return
 				}
 			}
 		}
@@ -159,12 +228,23 @@ func (addon *DevToolAddon) Run() {
 }
 
 func (addon *DevToolAddon) staticTopologyObserver(node Node, when string, s Signal, data ...interface{}) error {
+	if when == "unlink" {
+		addon.Lock()
+		addon.removeEdge(data[1].(*Edge))
+		addon.Unlock()
+		return nil
+	}
+
 	if when != "to" {
 		return nil
 	}
 
 	addon.Lock()
 	downstream := data[0].(Node)
+	edge := data[1].(*Edge)
+
+	// the edge is linked again before the removal is pushed
+	addon.removed = filterEdges(addon.removed, node, downstream)
 
 	// add nodes to elements list
 	addon.elements = append(addon.elements, handleNode(node))
@@ -179,17 +259,47 @@ func (addon *DevToolAddon) staticTopologyObserver(node Node, when string, s Sign
 	}
 
 	// handle edges
-	addon.elements = append(addon.elements, handleEdge(node, downstream))
+	addon.elements = append(addon.elements, handleEdge(edge))
 	addon.Unlock()
 
 	return nil
 }
 
+// removeEdge remove an edge, must be called with the lock held
+func (addon *DevToolAddon) removeEdge(edge *Edge) {
+	pending := len(addon.elements)
+	addon.elements = filterEdges(addon.elements, edge.Source, edge.Target)
+	if len(addon.elements) < pending {
+		// the edge was not pushed yet
+		return
+	}
+
+	addon.removed = append(addon.removed, handleEdge(edge))
+}
+
+// filterEdges get the elements without the edges between two nodes
+func filterEdges(elements []elemType, upstream Node, downstream Node) []elemType {
+	filtered := []elemType{}
+	for _, elem := range elements {
+		if elem.Group == "edges" && elem.Data.Source == upstream.ID() && elem.Data.Target == downstream.ID() {
+			continue
+		}
+		filtered = append(filtered, elem)
+	}
+	return filtered
+}
+
 func (addon *DevToolAddon) signalFlowObserver(node Node, when string, s Signal, data ...interface{}) error {
 	if when != "onReceive" && when != "send" {
 		return nil
 	}
 
+	addon.updateStats(node, when, s)
+
+	if !addon.accept(node, s) {
+		return nil
+	}
+
 	signalElem := signalType{
 		When:    when,
 		Time:    time.Now().UnixNano() / int64(time.Millisecond),
@@ -201,13 +311,115 @@ func (addon *DevToolAddon) signalFlowObserver(node Node, when string, s Signal,
 	}
 
 	addon.Lock()
-	addon.signals = append(addon.signals, signalElem)
+	if addon.options.MaxBuffer > 0 && len(addon.signals) >= addon.options.MaxBuffer {
+		addon.dropped++
+	} else {
+		addon.signals = append(addon.signals, signalElem)
+	}
 	addon.Unlock()
 
 	return nil
 }
 
+// updateStats update the statistics of the node
+func (addon *DevToolAddon) updateStats(node Node, when string, s Signal) {
+	addon.Lock()
+	defer addon.Unlock()
+
+	stats, ok := addon.stats[node.ID()]
+	if !ok {
+		stats = &nodeStats{}
+		addon.stats[node.ID()] = stats
+	}
+
+	switch {
+	case s.Error != nil && when == "send":
+		stats.Errors++
+		stats.Sent++
+	case when == "send":
+		stats.Sent++
+	default:
+		stats.Received++
+	}
+	stats.LastTime = time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// eventObserver notify the dev server when a node stops at a breakpoint or a circuit changes state
+func (addon *DevToolAddon) eventObserver(node Node, when string, s Signal, data ...interface{}) error {
+	switch when {
+	case "breakpoint":
+		addon.client.Emit("breakpoint hit", map[string]interface{}{
+			"nodeId":     node.ID(),
+			"breakpoint": data[0],
+			"seq":        s.ID,
+			"payload":    s.Payload,
+		})
+	case "circuit":
+		addon.client.Emit("node state", map[string]interface{}{
+			"nodeId": node.ID(),
+			"from":   data[0].(CircuitState).String(),
+			"state":  data[1].(CircuitState).String(),
+			"time":   time.Now().UnixNano() / int64(time.Millisecond),
+		})
+	}
+
+	return nil
+}
+
+// accept check if the signal observed on the node should be recorded
+func (addon *DevToolAddon) accept(node Node, s Signal) bool {
+	if len(addon.options.Namespaces) > 0 {
+		matched := false
+		for _, ns := range addon.options.Namespaces {
+			if node.Namespace() == ns || strings.HasPrefix(node.Namespace(), ns+".") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(addon.options.Tags) > 0 {
+		matched := false
+		for _, tag := range addon.options.Tags {
+			if node.HasTag(tag) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return sampleSignal(s, addon.options.SampleRate)
+}
+
+// sampleSignal decide if a signal is sampled, the decision only depends on the signal id,
+// so that a sampled signal can be followed through the whole graph
+func sampleSignal(s Signal, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(s.ID))
+	return float64(h.Sum32()) < rate*float64(math.MaxUint32)
+}
+
 func (addon *DevToolAddon) pushBufferedElements() {
+	if len(addon.removed) > 0 {
+		addon.client.Emit("remove elements", map[string]interface{}{
+			"elements": addon.removed,
+		})
+		addon.removed = []elemType{}
+	}
+
 	if len(addon.elements) <= 0 {
 		return
 	}
@@ -239,68 +451,235 @@ func (addon *DevToolAddon) pushBufferedSignals() {
 
 	addon.client.Emit("append signals", map[string]interface{}{
 		"signals": signalToBeSent,
+		"dropped": addon.dropped,
 	})
+	addon.dropped = 0
 }
 
-// CreateDevToolAddon create a new development addon
+// CreateDevToolAddon create a new development addon with default options
 func CreateDevToolAddon(url string) Addon {
+	return CreateDevToolAddonWithOptions(url, DefaultDevToolOptions())
+}
+
+// CreateDevToolAddonWithOptions create a new development addon
+func CreateDevToolAddonWithOptions(url string, options DevToolOptions) Addon {
 	client := CreateWebsocketClient(url, "", "")
 
+	if options.SampleRate <= 0 {
+		options.SampleRate = DefaultDevToolOptions().SampleRate
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = DefaultDevToolOptions().FlushInterval
+	}
+
 	addon := DevToolAddon{
 		observers: []Observer{},
 		elements:  []elemType{},
+		removed:   []elemType{},
 		signals:   []signalType{},
+		options:   options,
 		client:    &client,
 		nodes:     map[string]Node{},
+		stats:     map[string]*nodeStats{},
 		command:   make(chan string),
 	}
 	addon.observers = append(addon.observers, addon.staticTopologyObserver)
 	addon.observers = append(addon.observers, addon.signalFlowObserver)
+	addon.observers = append(addon.observers, addon.eventObserver)
 
-	client.On("push", func(data interface{}) error {
-		mapData := data.(map[string]interface{})
-		nodeId, ok := mapData["nodeId"]
+	client.On("push", addon.handlePush)
+	client.On("send", addon.handleSend)
+	client.On("pause", addon.handlePause)
+	client.On("resume", addon.handleResume)
+	client.On("breakpoint", addon.handleBreakpoint)
+	client.On("step", addon.handleStep)
+	client.On("error", addon.handleError)
+	client.On("stats", addon.handleStats)
 
-		payload, _ := mapData["signal"].(map[string]interface{})["payload"]
+	return &addon
+}
 
-		id := nodeId.(string)
+/**
+ * Remote control commands
+ */
 
-		if !ok {
-			log.Println("Failed to push data: data don't have nodeId property")
-			return nil
-		}
+// devToolBreakpoint the name of the breakpoint set from the dev tool
+const devToolBreakpoint = "devtool"
 
-		if node, ok := addon.nodes[id]; ok {
-			log.Println(node.Downstreams())
-			node.Push(payload)
-		} else {
-			log.Println("Failed to push data: couldn't find node with id:", id)
-		}
+// findNode find the node targeted by a command message
+func (addon *DevToolAddon) findNode(command string, data interface{}) (Node, map[string]interface{}, error) {
+	mapData, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, nil, errors.New("Failed to " + command + ": invalid message data")
+	}
+
+	id, ok := mapData["nodeId"].(string)
+	if !ok {
+		return nil, mapData, errors.New("Failed to " + command + ": data don't have nodeId property")
+	}
+
+	addon.RLock()
+	node, ok := addon.nodes[id]
+	addon.RUnlock()
+	if !ok {
+		return nil, mapData, errors.New("Failed to " + command + ": couldn't find node with id: " + id)
+	}
+
+	return node, mapData, nil
+}
+
+// signalPayload get the payload of the signal in a push or send command,
+// a payload which is not an object is sent as an anonymous payload
+func signalPayload(command string, mapData map[string]interface{}) (interface{}, error) {
+	signal, _ := mapData["signal"].(map[string]interface{})
+	payload, ok := signal["payload"]
+	if !ok || payload == nil {
+		return nil, errors.New("Failed to " + command + ": data don't have signal payload")
+	}
+	return payload, nil
+}
+
+// handlePush push data to a node
+func (addon *DevToolAddon) handlePush(data interface{}) error {
+	node, mapData, err := addon.findNode("push data", data)
+	if err != nil {
+		return err
+	}
+
+	payload, err := signalPayload("push data", mapData)
+	if err != nil {
+		return err
+	}
+
+	node.Push(payload)
+
+	return nil
+}
+
+// handleSend send data from a node to its downstreams
+func (addon *DevToolAddon) handleSend(data interface{}) error {
+	node, mapData, err := addon.findNode("send data", data)
+	if err != nil {
+		return err
+	}
+
+	payload, err := signalPayload("send data", mapData)
+	if err != nil {
+		return err
+	}
+
+	node.Send(payload)
+
+	return nil
+}
+
+// handlePause pause a node, the signals it receives are held until it is resumed or stepped
+func (addon *DevToolAddon) handlePause(data interface{}) error {
+	node, _, err := addon.findNode("pause", data)
+	if err != nil {
+		return err
+	}
+
+	node.Pause()
+
+	return nil
+}
 
+// handleResume resume a node, release all held signals and clear its breakpoint
+func (addon *DevToolAddon) handleResume(data interface{}) error {
+	node, _, err := addon.findNode("resume", data)
+	if err != nil {
+		return err
+	}
+
+	node.RemoveBreakpoint(devToolBreakpoint)
+	node.Resume()
+
+	return nil
+}
+
+// handleBreakpoint set or clear (with "enabled": false) the breakpoint on a node,
+// with "field" and "value" the node only stops on signals having the payload field equal to value
+func (addon *DevToolAddon) handleBreakpoint(data interface{}) error {
+	node, mapData, err := addon.findNode("set breakpoint", data)
+	if err != nil {
+		return err
+	}
+
+	enabled, ok := mapData["enabled"].(bool)
+	if ok && !enabled {
+		node.RemoveBreakpoint(devToolBreakpoint)
 		return nil
+	}
+
+	field, hasField := mapData["field"].(string)
+	value := fmt.Sprint(mapData["value"])
+
+	node.AddBreakpoint(devToolBreakpoint, func(s Signal) bool {
+		if !hasField {
+			return true
+		}
+		v, ok := s.Get(field)
+		return ok && fmt.Sprint(v) == value
 	})
 
-	client.On("send", func(data interface{}) error {
-		mapData := data.(map[string]interface{})
-		nodeId, ok := mapData["nodeId"]
+	return nil
+}
 
-		payload, _ := mapData["signal"].(map[string]interface{})["payload"]
+// handleStep release the next held signal of a node
+func (addon *DevToolAddon) handleStep(data interface{}) error {
+	node, _, err := addon.findNode("step", data)
+	if err != nil {
+		return err
+	}
 
-		id := nodeId.(string)
+	node.Step()
 
-		if !ok {
-			log.Println("Failed to send data: data don't have nodeId property")
-			return nil
-		}
+	return nil
+}
+
+// handleError inject an error signal to a node
+func (addon *DevToolAddon) handleError(data interface{}) error {
+	node, mapData, err := addon.findNode("inject error", data)
+	if err != nil {
+		return err
+	}
+
+	message, ok := mapData["message"].(string)
+	if !ok {
+		message = "error injected by dev tool"
+	}
+
+	go node.Push(errors.New(message))
+
+	return nil
+}
+
+// handleStats emit the statistics of a node, or of all nodes when no nodeId is given
+func (addon *DevToolAddon) handleStats(data interface{}) error {
+	result := map[string]nodeStats{}
 
-		if node, ok := addon.nodes[id]; ok {
-			node.Send(payload)
+	if mapData, ok := data.(map[string]interface{}); ok && mapData["nodeId"] != nil {
+		node, _, err := addon.findNode("fetch stats", data)
+		if err != nil {
+			return err
+		}
+		addon.RLock()
+		if stats, ok := addon.stats[node.ID()]; ok {
+			result[node.ID()] = *stats
 		} else {
-			log.Println("Failed to send data: couldn't  don't find node with id:", id)
+			result[node.ID()] = nodeStats{}
+		}
+		addon.RUnlock()
+	} else {
+		addon.RLock()
+		for id, stats := range addon.stats {
+			result[id] = *stats
 		}
+		addon.RUnlock()
+	}
 
-		return nil
+	return addon.client.Emit("stats", map[string]interface{}{
+		"stats": result,
 	})
-
-	return &addon
 }
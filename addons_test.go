@@ -1,6 +1,7 @@
 package collargo
 
 import (
+	"context"
 	"fmt"
 	"runtime/debug"
 	"strings"
@@ -18,9 +19,10 @@ func TestDevToolAddon(t *testing.T) {
 		"module": "test",
 	})
 
-	sensor := ns.Sensor("test sensor", func(options string, send SendDataFunc) {
+	sensor := ns.Sensor("test sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
 		time.Sleep(1000 * time.Millisecond)
 		send(float64(10))
+		return nil
 	}, false)
 
 	sensor.Map("@double x2", func(s Signal) (Signal, error) {
@@ -104,7 +106,7 @@ func TestHandleNodeAndEdge(t *testing.T) {
 	})
 
 	elem := handleNode(n1)
-	edge := handleEdge(n1, n2)
+	edge := handleEdge(newEdge(n1, n2, EdgeOptions{Label: "print it", Tags: []string{"debug"}}))
 
 	assert.Equal(t, "processor", elem.Data.Model)
 	assert.Equal(t, "processor test", elem.Data.Label)
@@ -113,4 +115,210 @@ func TestHandleNodeAndEdge(t *testing.T) {
 
 	assert.Equal(t, n1.ID(), edge.Data.Source)
 	assert.Equal(t, n2.ID(), edge.Data.Target)
+	assert.Equal(t, "print it", edge.Data.Label)
+	assert.Equal(t, []string{"debug"}, edge.Data.Tags)
+}
+
+func TestDevToolSampling(t *testing.T) {
+	assert.True(t, sampleSignal(CreateSignal(1), 1))
+	assert.False(t, sampleSignal(CreateSignal(1), 0))
+
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		if sampleSignal(CreateSignal(i), 0.5) {
+			sampled++
+		}
+	}
+	assert.True(t, sampled > 300 && sampled < 700)
+
+	// the same signal is always sampled the same way
+	s := CreateSignal(1)
+	assert.Equal(t, sampleSignal(s, 0.3), sampleSignal(s, 0.3))
+}
+
+func TestDevToolBufferLimit(t *testing.T) {
+	options := DefaultDevToolOptions()
+	options.MaxBuffer = 2
+	addon := CreateDevToolAddonWithOptions("ws://localhost:7500/app", options).(*DevToolAddon)
+
+	n := CreateNode("test node", "com.collargo.test", passThroughSignalProcessor{})
+
+	for i := 0; i < 5; i++ {
+		addon.signalFlowObserver(n, "onReceive", CreateSignal(i))
+	}
+
+	assert.Equal(t, 2, len(addon.signals))
+	assert.Equal(t, int64(3), addon.Dropped())
+
+	// the buffer is released even if the dev server is not connected
+	addon.pushBufferedSignals()
+	assert.Equal(t, 0, len(addon.signals))
+	assert.Equal(t, int64(0), addon.Dropped())
+}
+
+func TestDevToolDefaultSampleRate(t *testing.T) {
+	options := DefaultDevToolOptions()
+	options.SampleRate = 0
+	addon := CreateDevToolAddonWithOptions("ws://localhost:7500/app", options).(*DevToolAddon)
+
+	n := CreateNode("test node", "com.collargo.test", passThroughSignalProcessor{})
+	addon.signalFlowObserver(n, "onReceive", CreateSignal(1))
+
+	assert.Equal(t, 1, len(addon.signals))
+}
+
+func TestDevToolFilters(t *testing.T) {
+	options := DefaultDevToolOptions()
+	options.Namespaces = []string{"com.collargo"}
+	options.Tags = []string{"debug"}
+	addon := CreateDevToolAddonWithOptions("ws://localhost:7500/app", options).(*DevToolAddon)
+
+	n1 := CreateNode("#debug", "com.collargo.test", passThroughSignalProcessor{})
+	n2 := CreateNode("no tag", "com.collargo.test", passThroughSignalProcessor{})
+	n3 := CreateNode("#debug", "com.collargox", passThroughSignalProcessor{})
+
+	addon.signalFlowObserver(n1, "send", CreateSignal(1))
+	addon.signalFlowObserver(n2, "send", CreateSignal(2))
+	addon.signalFlowObserver(n3, "send", CreateSignal(3))
+
+	assert.Equal(t, 1, len(addon.signals))
+	assert.Equal(t, n1.ID(), addon.signals[0].NodeId)
+}
+
+func TestDevToolPauseAndStep(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	addon := CreateDevToolAddon("ws://localhost:7500/app").(*DevToolAddon)
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+
+	received := []int{}
+	double := ns.Map("x2", func(s Signal) (Signal, error) {
+		v, _ := s.Get(AnonPayload)
+		received = append(received, v.(int))
+		return s.New(v.(int) * 2), nil
+	})
+	edge := input.Connect(double, EdgeOptions{Label: "x2"})
+	addon.staticTopologyObserver(input, "to", Signal{}, double, edge)
+
+	assert.Nil(t, addon.handlePause(map[string]interface{}{"nodeId": double.ID()}))
+	input.Push(1)
+	input.Push(2)
+	assert.Equal(t, 0, len(received))
+
+	assert.Nil(t, addon.handleStep(map[string]interface{}{"nodeId": double.ID()}))
+	assert.Equal(t, []int{1}, received)
+
+	assert.Nil(t, addon.handleResume(map[string]interface{}{"nodeId": double.ID()}))
+	assert.Equal(t, []int{1, 2}, received)
+
+	assert.False(t, double.Paused())
+
+	assert.Nil(t, addon.handleBreakpoint(map[string]interface{}{
+		"nodeId": double.ID(),
+		"field":  AnonPayload,
+		"value":  4,
+	}))
+	input.Push(3)
+	input.Push(4)
+	assert.Equal(t, []int{1, 2, 3}, received)
+	assert.True(t, double.Paused())
+
+	assert.Nil(t, addon.handleResume(map[string]interface{}{"nodeId": double.ID()}))
+	assert.Equal(t, []int{1, 2, 3, 4}, received)
+
+	assert.NotNil(t, addon.handlePause(map[string]interface{}{"nodeId": "unknown"}))
+	assert.NotNil(t, addon.handlePause(map[string]interface{}{}))
+}
+
+func TestDevToolPushPayload(t *testing.T) {
+	addon := CreateDevToolAddon("ws://localhost:7500/app").(*DevToolAddon)
+
+	n := CreateNode("test node", "com.collargo.test", passThroughSignalProcessor{})
+	addon.nodes[n.ID()] = n
+
+	received := []Signal{}
+	n.Observe(func(node Node, when string, s Signal, data ...interface{}) error {
+		if when == "onReceive" {
+			received = append(received, s)
+		}
+		return nil
+	})
+
+	push := func(payload interface{}) error {
+		return addon.handlePush(map[string]interface{}{
+			"nodeId": n.ID(),
+			"signal": map[string]interface{}{"payload": payload},
+		})
+	}
+
+	assert.Nil(t, push(map[string]interface{}{"a": 1.0}))
+	assert.Nil(t, push("hello"))
+	assert.NotNil(t, push(nil))
+	assert.NotNil(t, addon.handleSend(map[string]interface{}{"nodeId": n.ID()}))
+
+	assert.Equal(t, 2, len(received))
+	assert.Equal(t, 1.0, received[0].Payload["a"])
+	v, _ := received[1].Get(AnonPayload)
+	assert.Equal(t, "hello", v)
+}
+
+func TestDevToolInjectErrorAndStats(t *testing.T) {
+	addon := CreateDevToolAddon("ws://localhost:7500/app").(*DevToolAddon)
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+
+	errCh := make(chan error, 1)
+	handler := ns.Errors("error handler", func(s Signal, rethrow SendSignalFunc) error {
+		errCh <- s.Error
+		return nil
+	})
+	handler.Observe(addon.signalFlowObserver)
+	edge := input.Connect(handler, EdgeOptions{Label: "errors"})
+	addon.staticTopologyObserver(input, "to", Signal{}, handler, edge)
+
+	assert.Nil(t, addon.handleError(map[string]interface{}{
+		"nodeId":  handler.ID(),
+		"message": "injected",
+	}))
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, "injected", err.Error())
+	case <-time.After(testDelay * time.Millisecond):
+		assert.Fail(t, "error signal not received")
+	}
+
+	addon.RLock()
+	assert.Equal(t, int64(1), addon.stats[handler.ID()].Received)
+	addon.RUnlock()
+
+	// not connected to dev server
+	assert.Equal(t, ErrNotConnected, addon.handleStats(map[string]interface{}{"nodeId": handler.ID()}))
+}
+
+func TestDevToolUnlink(t *testing.T) {
+	addon := CreateDevToolAddon("ws://localhost:7500/app").(*DevToolAddon)
+
+	n1 := CreateNode("test node 1", "com.collargo.test", passThroughSignalProcessor{})
+	n2 := CreateNode("test node 2", "com.collargo.test", passThroughSignalProcessor{})
+
+	// the pending edge is dropped
+	edge := newEdge(n1, n2, EdgeOptions{})
+	addon.staticTopologyObserver(n1, "to", Signal{}, n2, edge)
+	addon.staticTopologyObserver(n1, "unlink", Signal{}, n2, edge)
+	assert.Equal(t, 2, len(addon.elements))
+	assert.Equal(t, 0, len(addon.removed))
+
+	// the pushed edge is removed
+	edge = newEdge(n1, n2, EdgeOptions{})
+	addon.staticTopologyObserver(n1, "to", Signal{}, n2, edge)
+	addon.pushBufferedElements()
+	addon.staticTopologyObserver(n1, "unlink", Signal{}, n2, edge)
+	assert.Equal(t, 1, len(addon.removed))
+	assert.Equal(t, n1.ID(), addon.removed[0].Data.Source)
+	assert.Equal(t, n2.ID(), addon.removed[0].Data.Target)
+	assert.Equal(t, edge.ID, addon.removed[0].Data.ID)
 }
@@ -0,0 +1,188 @@
+package collargo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+/**
+ * Channel sensor
+ */
+
+// ChanSensor the sensor sending the values received from a channel once started,
+// and an end signal when the channel is closed
+type ChanSensor struct {
+	Sensor
+	ch reflect.Value
+}
+
+// createChanSensor create a channel sensor, panics if ch is not a channel it can receive from
+func createChanSensor(ns Namespace, comment string, ch interface{}) *ChanSensor {
+	value := reflect.ValueOf(ch)
+	if value.Kind() != reflect.Chan || value.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic("FromChan expects a receivable channel, got " + reflect.TypeOf(ch).String())
+	}
+
+	sensor := &ChanSensor{
+		ch: value,
+	}
+
+	sensor.Sensor = ns.Sensor(comment, sensor.watch, true)
+	sensor.SetType("sensor.chan")
+
+	return sensor
+}
+
+// Start start to receive from the channel
+func (sensor *ChanSensor) Start() *ChanSensor {
+	sensor.Watch(SensorOptions{})
+	return sensor
+}
+
+// watch send the received values until the channel is closed or the sensor is stopped,
+// the signals and the errors are sent as they are
+func (sensor *ChanSensor) watch(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: sensor.ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	}
+
+	for {
+		chosen, value, ok := reflect.Select(cases)
+		if chosen == 1 {
+			return nil
+		}
+		if !ok {
+			send(CreateSignal(map[string]interface{}{}).SetEnd(true))
+			return nil
+		}
+		send(chanData(value.Interface()))
+	}
+}
+
+// chanData convert a received value to the data of a signal: nil is the anonymous payload, the maps with
+// string keys are the payload, the maps with other keys are error signals
+func chanData(data interface{}) interface{} {
+	if data == nil {
+		return map[string]interface{}{AnonPayload: nil}
+	}
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Map {
+		return data
+	}
+	if value.Type().Key().Kind() != reflect.String {
+		return errors.New("FromChan can't send a " + value.Type().String() + " value")
+	}
+
+	payload := map[string]interface{}{}
+	for _, key := range value.MapKeys() {
+		payload[key.String()] = value.MapIndex(key).Interface()
+	}
+	return payload
+}
+
+/**
+ * Signal Processor for channel sink
+ */
+
+type chanSinkProcessor struct {
+	ch chan<- Signal
+}
+
+func (processor chanSinkProcessor) OnError(s Signal, send SendSignalFunc) error {
+	return processor.OnSignal(s, send)
+}
+
+func (processor chanSinkProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	// blocks until the signal is received, the graph is slowed down by the reader
+	processor.ch <- s
+	send(s)
+	return nil
+}
+
+// ChanSink the channel sink operator, sending each received signal (error and end signals included) to a channel
+//
+// the channel is not closed, the reader can stop at the end signal
+type ChanSink struct {
+	Node
+}
+
+// createChanSink create a channel sink node
+func createChanSink(namespace string, ch chan<- Signal) ChanSink {
+	sinkNode := CreateNode("to chan", namespace, chanSinkProcessor{
+		ch: ch,
+	})
+	sinkNode.SetType("sink.chan")
+	// the signals are received in the order they are sent
+	sinkNode.Ordered()
+
+	return ChanSink{
+		Node: sinkNode,
+	}
+}
+
+/**
+ * Signal Processor for collector
+ */
+
+type collectorProcessor struct {
+	sync.Mutex
+	signals []Signal
+	done    chan struct{}
+	ended   bool
+}
+
+func (processor *collectorProcessor) OnError(s Signal, send SendSignalFunc) error {
+	return processor.OnSignal(s, send)
+}
+
+func (processor *collectorProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	processor.Lock()
+	processor.signals = append(processor.signals, s)
+	if s.End && !processor.ended {
+		processor.ended = true
+		close(processor.done)
+	}
+	processor.Unlock()
+
+	send(s)
+	return nil
+}
+
+// Collector the collector operator, keeping the received signals (error and end signals included)
+type Collector struct {
+	Node
+	processor *collectorProcessor
+}
+
+// createCollector create a collector node
+func createCollector(namespace string) Collector {
+	processor := &collectorProcessor{
+		signals: []Signal{},
+		done:    make(chan struct{}),
+	}
+
+	collectorNode := CreateNode("collect", namespace, processor)
+	collectorNode.SetType("sink.collector")
+	// the end signal is received after the signals sent before it
+	collectorNode.Ordered()
+
+	return Collector{
+		Node:      collectorNode,
+		processor: processor,
+	}
+}
+
+// Signals get a copy of the signals received so far
+func (collector Collector) Signals() []Signal {
+	collector.processor.Lock()
+	defer collector.processor.Unlock()
+	return append([]Signal{}, collector.processor.signals...)
+}
+
+// Done get a channel closed when the first end signal is received
+func (collector Collector) Done() <-chan struct{} {
+	return collector.processor.done
+}
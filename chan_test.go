@@ -0,0 +1,119 @@
+package collargo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFromChan(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	ch := make(chan int)
+	sensor := ns.FromChan("numbers", ch)
+	assert.Equal(t, "sensor.chan", sensor.Type())
+
+	collector := sensor.Map("x2", func(s Signal) (Signal, error) {
+		if s.End {
+			return s, nil
+		}
+		v, _ := s.Get(AnonPayload)
+		return s.New(v.(int) * 2), nil
+	}).Ordered().Collect()
+	assert.Equal(t, "sink.collector", collector.Type())
+
+	sensor.Start()
+	for i := 1; i <= 3; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	select {
+	case <-collector.Done():
+	case <-time.After(testDelay * time.Millisecond):
+		assert.Fail(t, "the end signal is not received")
+	}
+
+	signals := collector.Signals()
+	assert.Equal(t, 4, len(signals))
+	assert.Equal(t, []interface{}{2, 4, 6}, payloadValues(signals[:3], AnonPayload))
+	assert.True(t, signals[3].End)
+
+	assert.Panics(t, func() {
+		ns.FromChan("not a chan", 1)
+	})
+	assert.Panics(t, func() {
+		ns.FromChan("send only", make(chan<- int))
+	})
+}
+
+func TestFromChanSignals(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	ch := make(chan interface{}, 3)
+	ch <- CreateSignal(1).SetTag("source", "test")
+	ch <- errors.New("error")
+	close(ch)
+
+	sensor := ns.FromChan("signals", (<-chan interface{})(ch))
+	collector := sensor.Collect()
+	sensor.Start()
+
+	<-collector.Done()
+	signals := collector.Signals()
+	assert.Equal(t, 3, len(signals))
+	source, _ := signals[0].GetTag("source")
+	assert.Equal(t, "test", source)
+	assert.Equal(t, "error", signals[1].Error.Error())
+}
+
+func TestFromChanValues(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	ch := make(chan interface{}, 4)
+	ch <- nil
+	ch <- map[string]int{"count": 1}
+	ch <- Payload{"name": "collar"}
+	ch <- map[int]string{1: "one"}
+	close(ch)
+
+	sensor := ns.FromChan("values", ch)
+	collector := sensor.Collect()
+	sensor.Start()
+
+	<-collector.Done()
+	signals := collector.Signals()
+	assert.Equal(t, 5, len(signals))
+
+	v, ok := signals[0].Get(AnonPayload)
+	assert.True(t, ok)
+	assert.Nil(t, v)
+	assert.Equal(t, []interface{}{1}, payloadValues(signals[1:2], "count"))
+	assert.Equal(t, []interface{}{"collar"}, payloadValues(signals[2:3], "name"))
+	assert.NotNil(t, signals[3].Error)
+}
+
+func TestToChan(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+
+	ch := make(chan Signal)
+	sink := input.ToChan(ch)
+	assert.Equal(t, "sink.chan", sink.Type())
+
+	go func() {
+		input.Push(1)
+		time.Sleep(10 * time.Millisecond)
+		input.Push(CreateSignal(2).SetEnd(true))
+	}()
+
+	received := []Signal{}
+	for s := range ch {
+		received = append(received, s)
+		if s.End {
+			break
+		}
+	}
+	assert.Equal(t, []interface{}{1, 2}, payloadValues(received, AnonPayload))
+}
@@ -0,0 +1,238 @@
+package collargo
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed signals are processed
+	CircuitClosed CircuitState = iota
+	// CircuitOpen signals are short-circuited with a CircuitOpenError
+	CircuitOpen
+	// CircuitHalfOpen signals are processed to test if the circuit can be closed
+	CircuitHalfOpen
+)
+
+func (state CircuitState) String() string {
+	switch state {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreakerOptions the options of the circuit breaker operator
+type CircuitBreakerOptions struct {
+	// FailureThreshold the number of consecutive failures opening the circuit
+	FailureThreshold int
+	// CoolDown the time the circuit stays open before letting signals through again
+	CoolDown time.Duration
+	// HalfOpenSuccesses the number of successes in half open state needed to close the circuit,
+	// it is also the max number of signals let through at a time in half open state
+	HalfOpenSuccesses int
+}
+
+// DefaultCircuitBreakerOptions the default options of the circuit breaker operator
+func DefaultCircuitBreakerOptions() CircuitBreakerOptions {
+	return CircuitBreakerOptions{
+		FailureThreshold:  5,
+		CoolDown:          30 * time.Second,
+		HalfOpenSuccesses: 1,
+	}
+}
+
+// CircuitOpenError the error of a signal short-circuited by an open circuit breaker
+type CircuitOpenError struct {
+	NodeID  string    // the id of the circuit breaker node
+	RetryAt time.Time // the time the circuit will let signals through again
+}
+
+func (err *CircuitOpenError) Error() string {
+	return "circuit open, retry at " + err.RetryAt.Format(time.RFC3339)
+}
+
+// IsCircuitOpen check if the error is a short-circuit error
+func IsCircuitOpen(err error) bool {
+	_, ok := err.(*CircuitOpenError)
+	return ok
+}
+
+/**
+ * Signal Processor for circuit breaker operator
+ */
+
+type circuitBreakerProcessor struct {
+	sync.Mutex
+	act     ActCallback
+	options CircuitBreakerOptions
+	node    *node
+
+	state     CircuitState
+	failures  int
+	successes int
+	openedAt  time.Time
+	probes    int // the number of probes in flight in half open state
+	halfOpens int // the number of times the circuit was half opened, identifying the probes
+}
+
+func newCircuitBreakerProcessor(act ActCallback, options CircuitBreakerOptions) *circuitBreakerProcessor {
+	if options.FailureThreshold < 1 {
+		options.FailureThreshold = 1
+	}
+	if options.HalfOpenSuccesses < 1 {
+		options.HalfOpenSuccesses = 1
+	}
+	return &circuitBreakerProcessor{
+		act:     act,
+		options: options,
+		state:   CircuitClosed,
+	}
+}
+
+func (breaker *circuitBreakerProcessor) OnError(s Signal, send SendSignalFunc) error {
+	send(s)
+	return nil
+}
+
+func (breaker *circuitBreakerProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	probe, err := breaker.allow(s)
+	if err != nil {
+		return err
+	}
+
+	result, err := breaker.act(s)
+
+	breaker.record(s, probe, err)
+
+	if err != nil {
+		return err
+	}
+
+	newSignal := s.SetResult(result)
+	send(newSignal)
+	return nil
+}
+
+func (breaker *circuitBreakerProcessor) getState() CircuitState {
+	breaker.Lock()
+	defer breaker.Unlock()
+	return breaker.state
+}
+
+// allow check if the signal can go through, returns a CircuitOpenError if not.
+// In half open state, only HalfOpenSuccesses probes are let through at a time, the returned probe
+// identifies the half open period the signal probes, 0 if the signal is not a probe
+func (breaker *circuitBreakerProcessor) allow(s Signal) (probe int, err error) {
+	breaker.Lock()
+	if breaker.state == CircuitClosed {
+		breaker.Unlock()
+		return 0, nil
+	}
+
+	halfOpened := false
+	if breaker.state == CircuitOpen {
+		retryAt := breaker.openedAt.Add(breaker.options.CoolDown)
+		if time.Now().Before(retryAt) {
+			breaker.Unlock()
+			return 0, &CircuitOpenError{
+				NodeID:  breaker.node.ID(),
+				RetryAt: retryAt,
+			}
+		}
+
+		breaker.state = CircuitHalfOpen
+		breaker.successes = 0
+		breaker.probes = 0
+		breaker.halfOpens++
+		halfOpened = true
+	}
+
+	// the other signals are short-circuited until the probes in flight complete
+	if breaker.probes+breaker.successes >= breaker.options.HalfOpenSuccesses {
+		breaker.Unlock()
+		return 0, &CircuitOpenError{
+			NodeID:  breaker.node.ID(),
+			RetryAt: time.Now(),
+		}
+	}
+	breaker.probes++
+	probe = breaker.halfOpens
+	breaker.Unlock()
+
+	if halfOpened {
+		breaker.node.notify("circuit", s, CircuitOpen, CircuitHalfOpen)
+	}
+	return probe, nil
+}
+
+// record record the result of a call, and open or close the circuit accordingly.
+// In half open state, only the results of the probes of the current half open period are counted
+func (breaker *circuitBreakerProcessor) record(s Signal, probe int, err error) {
+	breaker.Lock()
+	from := breaker.state
+	to := from
+
+	current := probe != 0 && probe == breaker.halfOpens
+	if current {
+		breaker.probes--
+	}
+
+	if from == CircuitHalfOpen && !current {
+		breaker.Unlock()
+		return
+	}
+
+	if err != nil {
+		breaker.failures++
+		if from == CircuitHalfOpen || breaker.failures >= breaker.options.FailureThreshold {
+			to = CircuitOpen
+		}
+	} else {
+		breaker.failures = 0
+		if from == CircuitHalfOpen {
+			breaker.successes++
+			if breaker.successes >= breaker.options.HalfOpenSuccesses {
+				to = CircuitClosed
+			}
+		}
+	}
+	breaker.Unlock()
+
+	if to != from {
+		breaker.transit(s, from, to)
+	}
+}
+
+// transit change the state and notify the observers with a "circuit" event
+func (breaker *circuitBreakerProcessor) transit(s Signal, from CircuitState, to CircuitState) {
+	breaker.Lock()
+	if breaker.state != from {
+		// another signal changed the state first
+		breaker.Unlock()
+		return
+	}
+	breaker.state = to
+	if to == CircuitOpen {
+		breaker.openedAt = time.Now()
+	}
+	breaker.Unlock()
+
+	breaker.node.notify("circuit", s, from, to)
+}
+
+// CircuitBreaker the circuit breaker operator, an actuator failing fast while the circuit is open
+type CircuitBreaker struct {
+	Node
+}
+
+// State get the state of the circuit
+func (breaker CircuitBreaker) State() CircuitState {
+	return breaker.SignalProcessor().(*circuitBreakerProcessor).getState()
+}
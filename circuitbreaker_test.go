@@ -0,0 +1,108 @@
+package collargo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+
+	down := true
+	calls := 0
+	breaker := input.CircuitBreaker("call service", func(s Signal) (interface{}, error) {
+		calls++
+		if down {
+			return nil, errors.New("service down")
+		}
+		return "ok", nil
+	}, CircuitBreakerOptions{
+		FailureThreshold:  2,
+		CoolDown:          50 * time.Millisecond,
+		HalfOpenSuccesses: 1,
+	})
+	assert.Equal(t, "circuitbreaker", breaker.Type())
+
+	transitions := []string{}
+	breaker.Observe(func(node Node, when string, s Signal, data ...interface{}) error {
+		if when == "circuit" {
+			transitions = append(transitions, data[1].(CircuitState).String())
+		}
+		return nil
+	})
+	sent := collectSignals(breaker)
+
+	input.Push(1)
+	input.Push(2)
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	input.Push(3)
+	assert.Equal(t, 2, calls)
+	signals := sent()
+	assert.Equal(t, 3, len(signals))
+	assert.False(t, IsCircuitOpen(signals[1].Error))
+	assert.True(t, IsCircuitOpen(signals[2].Error))
+
+	// failure in half open state opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	input.Push(4)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	down = false
+	time.Sleep(60 * time.Millisecond)
+	input.Push(5)
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	signals = sent()
+	result, _ := signals[len(signals)-1].GetResult()
+	assert.Equal(t, "ok", result)
+
+	assert.Equal(t, []string{"open", "half-open", "open", "half-open", "closed"}, transitions)
+}
+
+func TestCircuitBreakerHalfOpenProbes(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	breaker := ns.CircuitBreaker("call service", func(s Signal) (interface{}, error) {
+		release, ok := s.Payload[AnonPayload].(chan bool)
+		if !ok {
+			return nil, errors.New("service down")
+		}
+		<-release
+		return "ok", nil
+	}, CircuitBreakerOptions{
+		FailureThreshold:  1,
+		CoolDown:          10 * time.Millisecond,
+		HalfOpenSuccesses: 1,
+	})
+	sent := collectSignals(breaker)
+
+	breaker.Push(0)
+	assert.Equal(t, CircuitOpen, breaker.State())
+	time.Sleep(20 * time.Millisecond)
+
+	// the probe blocks until released, the signals received meanwhile are short-circuited
+	release := make(chan bool)
+	go breaker.Push(release)
+	for breaker.State() != CircuitHalfOpen {
+		time.Sleep(time.Millisecond)
+	}
+	breaker.Push(make(chan bool))
+	breaker.Push(make(chan bool))
+	signals := sent()
+	assert.Equal(t, 3, len(signals))
+	assert.True(t, IsCircuitOpen(signals[1].Error))
+	assert.True(t, IsCircuitOpen(signals[2].Error))
+
+	close(release)
+	signals = waitSignals(t, sent, 4)
+	assert.Nil(t, signals[3].Error)
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
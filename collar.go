@@ -1,17 +1,37 @@
 package collargo
 
 import (
+	"context"
 	// "log"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// flowDestTag the tag holding the ids of the outputs a flow function waits on, separated by commas
+const flowDestTag string = "__to_node_dest__"
+
 // CollarType the top level collar type
 type collarType struct {
+	sync.RWMutex
 	Namespace
 	// Observers the global observers for all nodes
 	observers []Observer
+	// the addons in use, with the observers they installed
+	addons []installedAddon
 	// Executor the executor
 	executor Executor
+	// the pending streaming flow function calls, by output id and signal id
+	streams map[string]*flowStream
+	// the number of activity trackers, the "done" and "delivered" events are only emitted when there is one
+	activityTrackers int32
+}
+
+// installedAddon an addon in use and its global observers
+type installedAddon struct {
+	addon     Addon
+	observers []Observer
 }
 
 type callbackResult struct {
@@ -21,15 +41,43 @@ type callbackResult struct {
 
 // SetExecutor set the executor
 func (collar *collarType) SetExecutor(executor Executor) {
+	collar.Lock()
 	collar.executor = executor
+	collar.Unlock()
 }
 
 // GetExecutor get the executor
-func (collar collarType) GetExecutor() Executor {
+func (collar *collarType) GetExecutor() Executor {
+	collar.RLock()
+	defer collar.RUnlock()
 	return collar.executor
 }
 
-func (collar collarType) NS(ns string, meta map[string]string) Namespace {
+// Observers get the global observers
+func (collar *collarType) Observers() []Observer {
+	collar.RLock()
+	defer collar.RUnlock()
+	return collar.observers
+}
+
+// TrackActivity emit the "done" and "delivered" observer events until the returned function is called,
+// they are not emitted by default as they are invoked for every signal processed and delivered
+func (collar *collarType) TrackActivity() func() {
+	atomic.AddInt32(&collar.activityTrackers, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt32(&collar.activityTrackers, -1)
+		})
+	}
+}
+
+// trackingActivity check if the "done" and "delivered" events should be emitted
+func (collar *collarType) trackingActivity() bool {
+	return atomic.LoadInt32(&collar.activityTrackers) > 0
+}
+
+func (collar *collarType) NS(ns string, meta map[string]string) Namespace {
 	return &namespaceType{
 		namespace: ns,
 		metadata:  meta,
@@ -39,47 +87,76 @@ func (collar collarType) NS(ns string, meta map[string]string) Namespace {
 func (collar *collarType) Use(addon Addon) {
 	obs := addon.Observers()
 
+	collar.Lock()
+	observers := make([]Observer, len(collar.observers), len(collar.observers)+len(obs))
+	copy(observers, collar.observers)
 	for i := range obs {
-		collar.observers = append(collar.observers, obs[i])
+		observers = append(observers, obs[i])
 	}
+	collar.observers = observers
+	collar.addons = append(collar.addons, installedAddon{
+		addon:     addon,
+		observers: obs,
+	})
+	collar.Unlock()
 
 	addon.Run()
 }
 
-func (collar *collarType) ToFlowFunc(input Node, output Node) FlowFunc {
-	_, existed := output.GetFlowOutputObserver()
-	if !existed {
-		observer := func(node Node, when string, signal Signal, data ...interface{}) error {
-			if when != "send" {
-				return nil
-			}
-
-			destTag, ok := signal.GetTag("__to_node_dest__")
-			if !ok || destTag != output.ID() {
-				return nil
-			}
-
-			cb, existed := output.GetSignalCallback(signal.ID)
-
-			if !existed {
-				return nil
-			}
-
-			output.DelSignalCallback(signal.ID)
+// Unuse stop an addon and remove its global observers, addons are compared by identity
+func (collar *collarType) Unuse(addon Addon) {
+	collar.Lock()
+	addons := []installedAddon{}
+	observers := []Observer{}
+	found := false
+	for _, installed := range collar.addons {
+		if installed.addon == addon {
+			found = true
+			continue
+		}
+		addons = append(addons, installed)
+		observers = append(observers, installed.observers...)
+	}
+	if found {
+		collar.addons = addons
+		collar.observers = observers
+	}
+	collar.Unlock()
 
-			if signal.Error != nil {
-				cb(signal.Error, nil)
-			} else {
-				cb(nil, signal.Payload)
-			}
+	if found {
+		addon.Stop()
+	}
+}
 
-			return nil
+// Replace rewire the graph to use the replacement node in place of the old node,
+// the old node is disconnected from all its upstreams and downstreams,
+// the upstreams of a node implemented outside of this package are only known if it implements UpstreamRegistry
+func (collar *collarType) Replace(old Node, replacement Node) {
+	upstreams := []*Edge{}
+	for _, up := range old.Upstreams() {
+		if edge, ok := up.Edges()[old.ID()]; ok {
+			upstreams = append(upstreams, edge)
 		}
+	}
+	downstreams := []*Edge{}
+	for _, edge := range old.Edges() {
+		downstreams = append(downstreams, edge)
+	}
 
-		output.SetFlowOutputObserver(observer)
+	// the edges keep their options
+	for _, edge := range upstreams {
+		edge.Source.Unlink(old)
+		edge.Source.Connect(replacement, edge.Options())
+	}
 
-		output.Observe(observer)
+	for _, edge := range downstreams {
+		old.Unlink(edge.Target)
+		replacement.Connect(edge.Target, edge.Options())
 	}
+}
+
+func (collar *collarType) ToFlowFunc(input Node, output Node) FlowFunc {
+	collar.observeFlowOutput(output)
 
 	flowFunc, existed := input.GetFlowFunc(output.ID())
 
@@ -89,9 +166,10 @@ func (collar *collarType) ToFlowFunc(input Node, output Node) FlowFunc {
 
 	flowFunc = func(data interface{}) (Payload, error) {
 		signal := CreateSignal(data)
-		signal = signal.SetTag("__to_node_dest__", output.ID())
+		signal = signal.SetTag(flowDestTag, output.ID())
 
-		ch := make(chan callbackResult)
+		// buffered, the callback may be invoked before waiting on the channel with a synchronous executor
+		ch := make(chan callbackResult, 1)
 		output.AddSignalCallback(signal.ID, func(err error, result Payload) {
 			if err != nil {
 				ch <- callbackResult{
@@ -117,6 +195,280 @@ func (collar *collarType) ToFlowFunc(input Node, output Node) FlowFunc {
 	return flowFunc
 }
 
+// ToContextFlowFunc convert a flow to a function returning the context error if it is done before the output is reached
+func (collar *collarType) ToContextFlowFunc(input Node, output Node) ContextFlowFunc {
+	collar.observeFlowOutput(output)
+
+	return func(ctx context.Context, data interface{}) (Payload, error) {
+		signal := CreateSignal(data)
+		signal = signal.SetTag(flowDestTag, output.ID())
+
+		ch := make(chan callbackResult, 1)
+		output.AddSignalCallback(signal.ID, func(err error, result Payload) {
+			ch <- callbackResult{
+				err:    err,
+				result: result,
+			}
+		})
+
+		input.Push(signal)
+
+		select {
+		case result := <-ch:
+			return result.result, result.err
+		case <-ctx.Done():
+			output.DelSignalCallback(signal.ID)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// ToMultiFlowFunc convert a flow with several named outputs (e.g. success and error endpoints) to a function,
+// resolved by the first output the signal reaches
+func (collar *collarType) ToMultiFlowFunc(input Node, outputs map[string]Node) MultiFlowFunc {
+	flowFunc := collar.ToContextMultiFlowFunc(input, outputs)
+
+	return func(data interface{}) (string, Payload, error) {
+		return flowFunc(context.Background(), data)
+	}
+}
+
+// ToContextMultiFlowFunc convert a flow with several named outputs to a function returning the context error
+// if it is done before an output is reached
+func (collar *collarType) ToContextMultiFlowFunc(input Node, outputs map[string]Node) ContextMultiFlowFunc {
+	ids := []string{}
+	for _, output := range outputs {
+		collar.observeFlowOutput(output)
+		ids = append(ids, output.ID())
+	}
+	dest := strings.Join(ids, ",")
+
+	return func(ctx context.Context, data interface{}) (string, Payload, error) {
+		signal := CreateSignal(data)
+		signal = signal.SetTag(flowDestTag, dest)
+
+		ch := make(chan multiCallbackResult, len(outputs))
+		for name, output := range outputs {
+			name := name
+			output.AddSignalCallback(signal.ID, func(err error, result Payload) {
+				ch <- multiCallbackResult{
+					output: name,
+					callbackResult: callbackResult{
+						err:    err,
+						result: result,
+					},
+				}
+			})
+		}
+
+		input.Push(signal)
+
+		var result multiCallbackResult
+		select {
+		case result = <-ch:
+		case <-ctx.Done():
+			result.err = ctx.Err()
+		}
+
+		for _, output := range outputs {
+			output.DelSignalCallback(signal.ID)
+		}
+		return result.output, result.result, result.err
+	}
+}
+
+// ToStreamFlowFunc convert a flow to a function returning all the results of the signal reaching the output,
+// including the ones of its child signals, until an end signal or the context is done
+func (collar *collarType) ToStreamFlowFunc(input Node, output Node) StreamFlowFunc {
+	collar.observeFlowOutput(output)
+
+	return func(ctx context.Context, data interface{}) <-chan FlowResult {
+		signal := CreateSignal(data)
+		signal = signal.SetTag(flowDestTag, output.ID())
+
+		ch := make(chan FlowResult)
+		stream := &flowStream{
+			ready: make(chan struct{}, 1),
+		}
+
+		key := streamKey(output.ID(), signal.ID)
+		collar.Lock()
+		collar.streams[key] = stream
+		collar.Unlock()
+
+		go func() {
+			stream.pump(ctx, ch)
+
+			// the stream is already removed after the end signal, not when the context is done
+			collar.Lock()
+			if collar.streams[key] == stream {
+				delete(collar.streams, key)
+			}
+			collar.Unlock()
+		}()
+
+		input.Push(signal)
+
+		return ch
+	}
+}
+
+// observeFlowOutput observe the output to resolve the flow functions waiting on it
+func (collar *collarType) observeFlowOutput(output Node) {
+	_, existed := output.GetFlowOutputObserver()
+	if existed {
+		return
+	}
+
+	observer := func(node Node, when string, signal Signal, data ...interface{}) error {
+		if when != "send" {
+			return nil
+		}
+
+		if !isFlowDest(signal, output.ID()) {
+			return nil
+		}
+
+		// the signals derived from the pushed signal are linked to it by the origin tag,
+		// a batched signal resolves all the pushed signals it groups
+		ids := []string{signal.ID}
+		if origin, ok := signal.GetTag(OriginTag); ok {
+			ids = append(ids, strings.Split(origin, ",")...)
+		}
+
+		for _, id := range ids {
+			if collar.deliverToStream(output.ID(), id, signal) {
+				continue
+			}
+
+			cb, existed := output.GetSignalCallback(id)
+
+			if !existed {
+				continue
+			}
+
+			output.DelSignalCallback(id)
+
+			if signal.Error != nil {
+				cb(signal.Error, nil)
+			} else {
+				cb(nil, signal.Payload)
+			}
+		}
+
+		return nil
+	}
+
+	output.SetFlowOutputObserver(observer)
+
+	output.Observe(observer)
+}
+
+// deliverToStream queue the result of the signal if a streaming flow function waits on it,
+// the stream is removed after the end signal
+func (collar *collarType) deliverToStream(outputID string, sigID string, signal Signal) bool {
+	key := streamKey(outputID, sigID)
+
+	collar.Lock()
+	stream, ok := collar.streams[key]
+	if ok && signal.End {
+		delete(collar.streams, key)
+	}
+	collar.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	result := FlowResult{
+		Err: signal.Error,
+	}
+	if signal.Error == nil {
+		result.Payload = signal.Payload
+	}
+	stream.add(result, signal.End)
+	return true
+}
+
+// isFlowDest check if a flow function waits on the signal at the output
+func isFlowDest(signal Signal, outputID string) bool {
+	dest, ok := signal.GetTag(flowDestTag)
+	if !ok {
+		return false
+	}
+	for _, id := range strings.Split(dest, ",") {
+		if id == outputID {
+			return true
+		}
+	}
+	return false
+}
+
+func streamKey(outputID string, sigID string) string {
+	return outputID + "/" + sigID
+}
+
+type multiCallbackResult struct {
+	callbackResult
+	output string
+}
+
+// flowStream the results of a streaming flow function call, queued until they are read
+type flowStream struct {
+	sync.Mutex
+	results []FlowResult
+	ended   bool
+	ready   chan struct{}
+}
+
+// add queue a result, without blocking the output node. The results arriving after the end are dropped
+func (stream *flowStream) add(result FlowResult, end bool) {
+	stream.Lock()
+	if stream.ended {
+		stream.Unlock()
+		return
+	}
+	stream.results = append(stream.results, result)
+	stream.ended = stream.ended || end
+	stream.Unlock()
+
+	select {
+	case stream.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pump forward the queued results to the channel, closed after the result of the end signal
+// or when the context is done
+func (stream *flowStream) pump(ctx context.Context, ch chan<- FlowResult) {
+	defer close(ch)
+
+	for {
+		select {
+		case <-stream.ready:
+		case <-ctx.Done():
+			return
+		}
+
+		stream.Lock()
+		results, ended := stream.results, stream.ended
+		stream.results = nil
+		stream.Unlock()
+
+		for _, result := range results {
+			select {
+			case ch <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if ended {
+			return
+		}
+	}
+}
+
 var (
 	defaultNS = namespaceType{
 		namespace: "",
@@ -129,6 +481,7 @@ var (
 	Collar = collarType{
 		Namespace: &defaultNS,
 		observers: []Observer{},
+		streams:   map[string]*flowStream{},
 		executor:  defaultExecutor{},
 	}
 
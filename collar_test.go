@@ -1,10 +1,11 @@
 package collargo
 
 import (
+	"context"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"testing"
-	// "time"
+	"time"
 )
 
 func TestToFlowFunc(t *testing.T) {
@@ -38,3 +39,272 @@ func TestToFlowFunc(t *testing.T) {
 	assert.Equal(t, 21, v.(int))
 	fmt.Println("assert", v)
 }
+
+func TestReplace(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	input := ns.Input("input")
+	output := ns.Output("output")
+
+	double := ns.Map("x2", func(s Signal) (Signal, error) {
+		v := new(IntPayload)
+		s.GetValue(AnonPayload, v)
+		return s.New(v.Value * 2), nil
+	})
+	triple := ns.Map("x3", func(s Signal) (Signal, error) {
+		v := new(IntPayload)
+		s.GetValue(AnonPayload, v)
+		return s.New(v.Value * 3), nil
+	})
+
+	input.To("x2", double).To("output", output)
+
+	flowFunc := Collar.ToFlowFunc(input, output)
+
+	r, err := flowFunc(10)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, r[AnonPayload])
+
+	Collar.Replace(double, triple)
+
+	assert.Equal(t, 0, len(double.Upstreams()))
+	assert.Equal(t, 0, len(double.Downstreams()))
+
+	r, err = flowFunc(10)
+	assert.Nil(t, err)
+	assert.Equal(t, 30, r[AnonPayload])
+}
+
+func TestToMultiFlowFunc(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	input := ns.Input("input")
+	success := ns.Output("success")
+	failure := ns.Output("failure")
+
+	input.When("positive", func(s Signal) (bool, error) {
+		v, _ := s.Get(AnonPayload)
+		return v.(int) >= 0, nil
+	}).To("success", success)
+	input.When("negative", func(s Signal) (bool, error) {
+		v, _ := s.Get(AnonPayload)
+		return v.(int) < 0, nil
+	}).To("failure", failure)
+
+	flowFunc := Collar.ToMultiFlowFunc(input, map[string]Node{
+		"success": success,
+		"failure": failure,
+	})
+
+	output, r, err := flowFunc(1)
+	assert.Nil(t, err)
+	assert.Equal(t, "success", output)
+	assert.Equal(t, 1, r[AnonPayload])
+
+	output, r, err = flowFunc(-1)
+	assert.Nil(t, err)
+	assert.Equal(t, "failure", output)
+	assert.Equal(t, -1, r[AnonPayload])
+
+	_, existed := success.GetSignalCallback(CreateSignal(0).ID)
+	assert.False(t, existed)
+}
+
+func TestToContextMultiFlowFunc(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	input := ns.Input("input")
+	success := ns.Output("success")
+	failure := ns.Output("failure")
+	input.When("positive", func(s Signal) (bool, error) {
+		v, _ := s.Get(AnonPayload)
+		return v.(int) >= 0, nil
+	}).To("success", success)
+
+	flowFunc := Collar.ToContextMultiFlowFunc(input, map[string]Node{
+		"success": success,
+		"failure": failure,
+	})
+
+	output, r, err := flowFunc(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "success", output)
+	assert.Equal(t, 1, r[AnonPayload])
+
+	// no output is reached by the negative values
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	output, _, err = flowFunc(ctx, -1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, "", output)
+}
+
+func TestNestedFlatMapFlowFunc(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	input := ns.Input("input")
+	output := ns.Output("output")
+	split := func(s Signal) ([]Signal, error) {
+		v, _ := s.Get(AnonPayload)
+		return []Signal{s.New(v.(int) * 10)}, nil
+	}
+	input.FlatMap("split", split).FlatMap("split again", split).To("output", output)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r, err := Collar.ToContextFlowFunc(input, output)(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 100, r[AnonPayload])
+
+	results := []interface{}{}
+	for r := range Collar.ToStreamFlowFunc(input, output)(ctx, 2) {
+		results = append(results, r.Payload[AnonPayload])
+		if len(results) == 1 {
+			cancel()
+		}
+	}
+	assert.Equal(t, []interface{}{200}, results)
+}
+
+func TestToStreamFlowFunc(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	input := ns.Input("input")
+	output := ns.Output("output")
+
+	input.FlatMap("split", func(s Signal) ([]Signal, error) {
+		v, _ := s.Get(AnonPayload)
+		signals := []Signal{}
+		for i := 1; i <= v.(int); i++ {
+			signals = append(signals, s.New(i))
+		}
+		signals[len(signals)-1] = signals[len(signals)-1].SetEnd(true)
+		return signals, nil
+	}).To("output", output)
+	// the end signal must reach the output after the others
+	output.Ordered()
+
+	streamFunc := Collar.ToStreamFlowFunc(input, output)
+
+	results := []interface{}{}
+	for r := range streamFunc(context.Background(), 3) {
+		assert.Nil(t, r.Err)
+		results = append(results, r.Payload[AnonPayload])
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, results)
+
+	Collar.RLock()
+	assert.Equal(t, 0, len(Collar.streams))
+	Collar.RUnlock()
+}
+
+func TestToStreamFlowFuncCancel(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	input := ns.Input("input")
+	output := ns.Output("output")
+	input.To("output", output)
+
+	streamFunc := Collar.ToStreamFlowFunc(input, output)
+
+	// the signal is not an end signal, the stream waits until the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := streamFunc(ctx, 1)
+	r := <-ch
+	assert.Equal(t, 1, r.Payload[AnonPayload])
+
+	cancel()
+	_, open := <-ch
+	assert.False(t, open)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		Collar.RLock()
+		streams := len(Collar.streams)
+		Collar.RUnlock()
+		if streams == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	Collar.RLock()
+	assert.Equal(t, 0, len(Collar.streams))
+	Collar.RUnlock()
+}
+
+func TestFlowStreamAfterEnd(t *testing.T) {
+	stream := &flowStream{
+		ready: make(chan struct{}, 1),
+	}
+	stream.add(FlowResult{}, true)
+	stream.add(FlowResult{}, false)
+
+	assert.True(t, stream.ended)
+	assert.Equal(t, 1, len(stream.results))
+}
+
+type countingAddon struct {
+	node    Node
+	done    int
+	stopped bool
+}
+
+func (addon *countingAddon) Observers() []Observer {
+	return []Observer{func(node Node, when string, s Signal, data ...interface{}) error {
+		if when == "done" && node.ID() == addon.node.ID() {
+			addon.done++
+		}
+		return nil
+	}}
+}
+
+func (addon *countingAddon) Run() {
+}
+
+func (addon *countingAddon) Stop() {
+	addon.stopped = true
+}
+
+func TestUnuse(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+	defer Collar.TrackActivity()()
+
+	n := CreateNode("test node", "com.collargo.test", passThroughSignalProcessor{})
+
+	observers := len(Collar.Observers())
+	addon := &countingAddon{node: n}
+	Collar.Use(addon)
+	assert.Equal(t, observers+1, len(Collar.Observers()))
+
+	n.Push(1)
+	assert.Equal(t, 1, addon.done)
+
+	Collar.Unuse(addon)
+	assert.True(t, addon.stopped)
+	assert.Equal(t, observers, len(Collar.Observers()))
+
+	n.Push(2)
+	assert.Equal(t, 1, addon.done)
+}
+
+func TestTrackActivity(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	n := CreateNode("test node", "com.collargo.test", passThroughSignalProcessor{})
+	addon := &countingAddon{node: n}
+	Collar.Use(addon)
+	defer Collar.Unuse(addon)
+
+	n.Push(1)
+	assert.Equal(t, 0, addon.done)
+
+	untrack := Collar.TrackActivity()
+	n.Push(2)
+	assert.Equal(t, 1, addon.done)
+
+	untrack()
+	untrack()
+	assert.False(t, Collar.trackingActivity())
+	n.Push(3)
+	assert.Equal(t, 1, addon.done)
+}
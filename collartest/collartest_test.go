@@ -0,0 +1,107 @@
+package collartest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bhou/collargo"
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestProbe(t *testing.T) {
+	ns := collargo.Collar.NS("com.collargo.collartest", map[string]string{})
+
+	sensor := CreateFakeSensor(ns, "numbers", 1, Wait(10*time.Millisecond), 2, 3)
+
+	double := sensor.Map("x2", func(s collargo.Signal) (collargo.Signal, error) {
+		v, ok := s.Get(collargo.AnonPayload)
+		if !ok {
+			return s, nil
+		}
+		if v.(int) == 3 {
+			return s, errors.New("three")
+		}
+		return s.New(v.(int) * 2), nil
+	}).Ordered()
+
+	probe := Attach(double)
+
+	sensor.Play()
+
+	probe.ExpectReceived(t, time.Second, 1, 2, 3)
+	probe.ExpectSent(t, time.Second, 2, 4, errors.New("three"))
+
+	r := &recorder{}
+	assert.False(t, probe.ExpectSent(r, time.Second, 2, 5, errors.New("three")))
+	assert.Equal(t, 1, len(r.errors))
+
+	r = &recorder{}
+	assert.False(t, probe.ExpectSent(r, 10*time.Millisecond, 2, 4, 6, 8))
+	assert.Equal(t, 1, len(r.errors))
+
+	probe.Reset()
+	assert.Equal(t, 0, len(probe.Received()))
+
+	sensorProbe := Attach(sensor)
+	sensor.Emit(map[string]interface{}{"value": 1})
+	sensorProbe.ExpectSent(t, time.Second, map[string]interface{}{"value": 1})
+	sensorProbe.ExpectSent(t, time.Second, Matcher(func(s collargo.Signal) bool {
+		v, _ := s.Get("value")
+		return v == 1
+	}))
+}
+
+func TestWaitIdle(t *testing.T) {
+	observers := len(collargo.Collar.Observers())
+	untrack := TrackActivity()
+
+	ns := collargo.Collar.NS("com.collargo.collartest", map[string]string{})
+	input := ns.Input("input")
+
+	done := false
+	input.Map("slow", func(s collargo.Signal) (collargo.Signal, error) {
+		time.Sleep(100 * time.Millisecond)
+		return s, nil
+	}).Do("done", func(s collargo.Signal) (interface{}, error) {
+		done = true
+		return nil, nil
+	})
+
+	// the slow signal is in flight longer than the quiet period
+	input.Push(1)
+	assert.Nil(t, WaitIdle(10*time.Millisecond, time.Second))
+	assert.True(t, done)
+
+	untrack()
+	assert.Equal(t, observers, len(collargo.Collar.Observers()))
+}
+
+func TestWaitIdleWithManualExecutor(t *testing.T) {
+	previous := collargo.Collar.GetExecutor()
+	executor := collargo.CreateManualExecutor()
+	collargo.Collar.SetExecutor(executor)
+	defer collargo.Collar.SetExecutor(previous)
+
+	ns := collargo.Collar.NS("com.collargo.collartest", map[string]string{})
+	input := ns.Input("input")
+	probe := Attach(input.Map("x2", func(s collargo.Signal) (collargo.Signal, error) {
+		v, _ := s.Get(collargo.AnonPayload)
+		return s.New(v.(int) * 2), nil
+	}))
+
+	input.Push(21)
+	assert.Equal(t, 0, len(probe.Sent()))
+
+	assert.Nil(t, WaitIdle(0, 0))
+	probe.ExpectSent(t, 0, 42)
+}
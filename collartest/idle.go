@@ -0,0 +1,159 @@
+package collartest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bhou/collargo"
+)
+
+// activityAddon counts the signals in flight and records the last time a signal was received or
+// sent by any node. A signal is in flight from the time a node sends it until it is delivered to the
+// downstream nodes, and from the time a node receives it until the node is done with it
+type activityAddon struct {
+	sync.RWMutex
+	pending  map[string]int
+	inFlight int
+	last     time.Time
+}
+
+var (
+	activityLock sync.Mutex
+	activity     *activityAddon
+)
+
+func (addon *activityAddon) Observers() []collargo.Observer {
+	return []collargo.Observer{addon.observe}
+}
+
+func (addon *activityAddon) Run() {
+}
+
+func (addon *activityAddon) Stop() {
+}
+
+func (addon *activityAddon) observe(node collargo.Node, when string, s collargo.Signal, data ...interface{}) error {
+	switch when {
+	case "onReceive":
+		addon.add(node.ID()+"/"+s.ID, 1)
+	case "done":
+		addon.add(node.ID()+"/"+s.ID, -1)
+	case "send":
+		if len(data) > 0 {
+			if edges, ok := data[0].(int); ok {
+				addon.add(node.ID()+">"+s.ID, edges)
+				return nil
+			}
+		}
+		addon.add("", 0)
+	case "delivered":
+		addon.add(node.ID()+">"+s.ID, -1)
+	}
+	return nil
+}
+
+// add update the number of signals in flight for a key, the signals received or sent before
+// the tracking started are not counted
+func (addon *activityAddon) add(key string, n int) {
+	addon.Lock()
+	defer addon.Unlock()
+
+	addon.last = time.Now()
+	if n == 0 {
+		return
+	}
+
+	count := addon.pending[key]
+	if count+n < 0 {
+		n = -count
+	}
+	count += n
+	addon.inFlight += n
+
+	if count == 0 {
+		delete(addon.pending, key)
+	} else {
+		addon.pending[key] = count
+	}
+}
+
+// idle get the number of signals in flight and the time since the last activity
+func (addon *activityAddon) idle() (int, time.Duration) {
+	addon.RLock()
+	defer addon.RUnlock()
+	return addon.inFlight, time.Since(addon.last)
+}
+
+// TrackActivity install the global observer used by WaitIdle, call it before pushing the first signals
+// so that they are counted as in flight. The returned function removes the observer:
+//
+//	defer collartest.TrackActivity()()
+func TrackActivity() func() {
+	_, untrack := trackActivity()
+	return untrack
+}
+
+func trackActivity() (*activityAddon, func()) {
+	addon := &activityAddon{
+		pending: map[string]int{},
+		last:    time.Now(),
+	}
+	collargo.Collar.Use(addon)
+	untrack := collargo.Collar.TrackActivity()
+
+	activityLock.Lock()
+	previous := activity
+	activity = addon
+	activityLock.Unlock()
+
+	return addon, func() {
+		untrack()
+		collargo.Collar.Unuse(addon)
+
+		activityLock.Lock()
+		if activity == addon {
+			activity = previous
+		}
+		activityLock.Unlock()
+	}
+}
+
+// WaitIdle wait until no signal is in flight and no signal was received or sent by any node during
+// the quiet period. The quiet period covers the signals a node sends later, after it is done with
+// the signal it received (e.g. debounce). Without TrackActivity, only the quiet period is checked.
+//
+// With a manual executor, the queued tasks are run until idle instead
+func WaitIdle(quiet time.Duration, timeout time.Duration) error {
+	if executor, ok := collargo.Collar.GetExecutor().(*collargo.ManualExecutor); ok {
+		executor.RunUntilIdle()
+		return nil
+	}
+
+	activityLock.Lock()
+	addon := activity
+	activityLock.Unlock()
+
+	if addon == nil {
+		var untrack func()
+		addon, untrack = trackActivity()
+		defer untrack()
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		inFlight, since := addon.idle()
+		if inFlight == 0 && since >= quiet {
+			return nil
+		}
+
+		wait := quiet - since
+		if inFlight > 0 || wait <= 0 {
+			wait = time.Millisecond
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("graph still active after %v, %d signals in flight", timeout, inFlight)
+		}
+		time.Sleep(wait)
+	}
+}
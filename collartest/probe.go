@@ -0,0 +1,186 @@
+// Package collartest provides helpers to test collar graphs: probes recording the signals
+// of a node, fake sensors emitting scripted data, and waiting for a graph to be idle.
+package collartest
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/bhou/collargo"
+)
+
+// TestingT the subset of testing.T used by the assertions
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Matcher a custom predicate on signals, usable as expected value in the assertions
+type Matcher func(s collargo.Signal) bool
+
+// Probe records the signals received and sent by a node
+type Probe struct {
+	sync.Mutex
+	node     collargo.Node
+	received []collargo.Signal
+	sent     []collargo.Signal
+	changed  chan struct{}
+}
+
+// Attach attach a probe to a node
+func Attach(node collargo.Node) *Probe {
+	probe := &Probe{
+		node:     node,
+		received: []collargo.Signal{},
+		sent:     []collargo.Signal{},
+		changed:  make(chan struct{}),
+	}
+
+	node.Observe(probe.observe)
+
+	return probe
+}
+
+// Node get the probed node
+func (probe *Probe) Node() collargo.Node {
+	return probe.node
+}
+
+// Received get the signals received by the node so far
+func (probe *Probe) Received() []collargo.Signal {
+	probe.Lock()
+	defer probe.Unlock()
+	return append([]collargo.Signal{}, probe.received...)
+}
+
+// Sent get the signals sent by the node so far
+func (probe *Probe) Sent() []collargo.Signal {
+	probe.Lock()
+	defer probe.Unlock()
+	return append([]collargo.Signal{}, probe.sent...)
+}
+
+// Reset forget the recorded signals
+func (probe *Probe) Reset() {
+	probe.Lock()
+	probe.received = []collargo.Signal{}
+	probe.sent = []collargo.Signal{}
+	probe.Unlock()
+}
+
+// WaitReceived wait until the node received at least n signals, returns the received signals
+func (probe *Probe) WaitReceived(n int, timeout time.Duration) ([]collargo.Signal, error) {
+	return probe.wait(n, timeout, func() []collargo.Signal {
+		return probe.received
+	})
+}
+
+// WaitSent wait until the node sent at least n signals, returns the sent signals
+func (probe *Probe) WaitSent(n int, timeout time.Duration) ([]collargo.Signal, error) {
+	return probe.wait(n, timeout, func() []collargo.Signal {
+		return probe.sent
+	})
+}
+
+// ExpectReceived assert the node receives the expected sequence within the timeout
+//
+// see Match for how the signals are compared with the expected values
+func (probe *Probe) ExpectReceived(t TestingT, timeout time.Duration, expected ...interface{}) bool {
+	signals, err := probe.WaitReceived(len(expected), timeout)
+	return expectSequence(t, "received", signals, err, expected)
+}
+
+// ExpectSent assert the node sends the expected sequence within the timeout
+//
+// see Match for how the signals are compared with the expected values
+func (probe *Probe) ExpectSent(t TestingT, timeout time.Duration, expected ...interface{}) bool {
+	signals, err := probe.WaitSent(len(expected), timeout)
+	return expectSequence(t, "sent", signals, err, expected)
+}
+
+func (probe *Probe) observe(node collargo.Node, when string, s collargo.Signal, data ...interface{}) error {
+	switch when {
+	case "onReceive":
+		probe.Lock()
+		probe.received = append(probe.received, s)
+		probe.notify()
+		probe.Unlock()
+	case "send":
+		probe.Lock()
+		probe.sent = append(probe.sent, s)
+		probe.notify()
+		probe.Unlock()
+	}
+	return nil
+}
+
+// notify wake up the waiting goroutines, must be called with the lock held
+func (probe *Probe) notify() {
+	close(probe.changed)
+	probe.changed = make(chan struct{})
+}
+
+func (probe *Probe) wait(n int, timeout time.Duration, signals func() []collargo.Signal) ([]collargo.Signal, error) {
+	deadline := time.After(timeout)
+	for {
+		probe.Lock()
+		current := append([]collargo.Signal{}, signals()...)
+		changed := probe.changed
+		probe.Unlock()
+
+		if len(current) >= n {
+			return current, nil
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return current, fmt.Errorf("timeout after %v: got %d signals, expected %d", timeout, len(current), n)
+		}
+	}
+}
+
+// Match check if a signal matches an expected value:
+//
+// a Matcher is called with the signal, an error is compared with the signal error,
+// a map is compared with the signal payload, and any other value with the anonymous payload
+func Match(s collargo.Signal, expected interface{}) bool {
+	switch e := expected.(type) {
+	case Matcher:
+		return e(s)
+	case func(collargo.Signal) bool:
+		return e(s)
+	case error:
+		return s.Error != nil && s.Error.Error() == e.Error()
+	case map[string]interface{}:
+		return s.Error == nil && reflect.DeepEqual(map[string]interface{}(s.Payload), e)
+	case collargo.Payload:
+		return s.Error == nil && reflect.DeepEqual(map[string]interface{}(s.Payload), map[string]interface{}(e))
+	default:
+		v, ok := s.Get(collargo.AnonPayload)
+		return s.Error == nil && ok && reflect.DeepEqual(v, expected)
+	}
+}
+
+func expectSequence(t TestingT, what string, signals []collargo.Signal, err error, expected []interface{}) bool {
+	if err != nil {
+		t.Errorf("expected %d signals %s: %v", len(expected), what, err)
+		return false
+	}
+
+	if len(signals) != len(expected) {
+		t.Errorf("expected %d signals %s, got %d", len(expected), what, len(signals))
+		return false
+	}
+
+	for i := range expected {
+		if !Match(signals[i], expected[i]) {
+			t.Errorf("signal %d %s doesn't match: expected %v, got payload %v (error: %v)",
+				i, what, expected[i], signals[i].Payload, signals[i].Error)
+			return false
+		}
+	}
+
+	return true
+}
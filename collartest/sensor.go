@@ -0,0 +1,51 @@
+package collartest
+
+import (
+	"context"
+	"time"
+
+	"github.com/bhou/collargo"
+)
+
+// wait a pause in the script of a fake sensor
+type wait time.Duration
+
+// Wait get a script step pausing the fake sensor for a duration
+func Wait(d time.Duration) interface{} {
+	return wait(d)
+}
+
+// FakeSensor a sensor emitting scripted data
+type FakeSensor struct {
+	collargo.Sensor
+	script []interface{}
+}
+
+// CreateFakeSensor create a fake sensor in the namespace, the script is a list of data to emit,
+// with optional Wait steps between them. Nothing is emitted until Play is called
+func CreateFakeSensor(ns collargo.Namespace, comment string, script ...interface{}) FakeSensor {
+	sensor := ns.Sensor(comment, func(ctx context.Context, options collargo.SensorOptions, send collargo.SendDataFunc) error {
+		return nil
+	}, true)
+
+	return FakeSensor{
+		Sensor: sensor,
+		script: script,
+	}
+}
+
+// Play emit the scripted data in order, on the calling goroutine
+func (sensor FakeSensor) Play() {
+	for _, step := range sensor.script {
+		if d, ok := step.(wait); ok {
+			time.Sleep(time.Duration(d))
+			continue
+		}
+		sensor.Send(step)
+	}
+}
+
+// Emit emit a single data
+func (sensor FakeSensor) Emit(data interface{}) {
+	sensor.Send(data)
+}
@@ -0,0 +1,288 @@
+package collargo
+
+import (
+	"container/list"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// DedupeOptions the options of the dedupe operator
+type DedupeOptions struct {
+	// TTL how long a key is remembered, 0 remembers keys forever. The expired keys are forgotten as new keys are seen
+	TTL time.Duration
+	// MaxSize the max number of keys remembered, the least recently seen are forgotten first,
+	// 0 for unbounded, only set it with a TTL or for a bounded set of keys
+	MaxSize int
+	// Path the file where the seen keys are appended, empty to keep them only in memory
+	Path string
+}
+
+// dedupeCompactThreshold the min number of keys appended to the file before it is compacted
+const dedupeCompactThreshold = 1000
+
+/**
+ * Signal Processor for dedupe operator
+ */
+
+type seenKey struct {
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires"`
+}
+
+// rememberedKey the positions of a remembered key in the lists of the processor
+type rememberedKey struct {
+	order  *list.Element
+	expiry *list.Element // nil if the key never expires
+}
+
+type dedupeProcessor struct {
+	sync.Mutex
+	key     KeyFunc
+	options DedupeOptions
+	order   *list.List // most recently seen first
+	expiry  *list.List // the keys expiring first first, the keys never expiring are not in it
+	seen    map[string]rememberedKey
+
+	// serialize the writes to the file, held without the processor lock
+	fileLock sync.Mutex
+	// the number of keys in the file, including the forgotten ones
+	logged int
+}
+
+// newDedupeProcessor create the processor of the dedupe operator, panics without key function
+// as the signal ids are unique and would only fill the memory
+func newDedupeProcessor(key KeyFunc, options DedupeOptions) *dedupeProcessor {
+	if key == nil {
+		panic("Dedupe expects a key function")
+	}
+
+	dedupe := &dedupeProcessor{
+		key:     key,
+		options: options,
+		order:   list.New(),
+		expiry:  list.New(),
+		seen:    map[string]rememberedKey{},
+	}
+
+	if options.Path != "" {
+		err := dedupe.load()
+		if err != nil && !os.IsNotExist(err) {
+			log.Println("Dedupe:", "failed to load seen keys from", options.Path, err)
+		}
+	}
+
+	return dedupe
+}
+
+func (dedupe *dedupeProcessor) OnError(s Signal, send SendSignalFunc) error {
+	send(s)
+	return nil
+}
+
+// OnSignal drop the signal if its key was already seen, signals without key are never dropped
+func (dedupe *dedupeProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	key := dedupe.key(s)
+	if key == "" {
+		send(s)
+		return nil
+	}
+
+	entry, duplicated := dedupe.duplicated(key)
+	if duplicated {
+		return nil
+	}
+
+	if dedupe.options.Path != "" {
+		err := dedupe.save(entry)
+		if err != nil {
+			log.Println("Dedupe:", "failed to persist seen keys to", dedupe.options.Path, err)
+		}
+	}
+
+	send(s)
+	return nil
+}
+
+// duplicated check if the key was already seen, and remember it otherwise
+func (dedupe *dedupeProcessor) duplicated(key string) (seenKey, bool) {
+	dedupe.Lock()
+	defer dedupe.Unlock()
+
+	now := time.Now()
+	dedupe.purge(now)
+
+	if remembered, ok := dedupe.seen[key]; ok {
+		dedupe.order.MoveToFront(remembered.order)
+		return remembered.order.Value.(seenKey), true
+	}
+
+	entry := seenKey{
+		Key: key,
+	}
+	if dedupe.options.TTL > 0 {
+		entry.Expires = now.Add(dedupe.options.TTL)
+	}
+	dedupe.remember(entry)
+
+	return entry, false
+}
+
+// purge forget the expired keys, must be called with the lock held
+func (dedupe *dedupeProcessor) purge(now time.Time) {
+	for elem := dedupe.expiry.Front(); elem != nil && !now.Before(elem.Value.(seenKey).Expires); elem = dedupe.expiry.Front() {
+		dedupe.forget(elem.Value.(seenKey).Key)
+	}
+}
+
+// remember add the key as the most recently seen, must be called with the lock held
+func (dedupe *dedupeProcessor) remember(entry seenKey) {
+	dedupe.forget(entry.Key)
+
+	remembered := rememberedKey{
+		order: dedupe.order.PushFront(entry),
+	}
+	if !entry.Expires.IsZero() {
+		// the keys are mostly seen in expiry order, the loaded ones may be out of order
+		mark := dedupe.expiry.Back()
+		for mark != nil && entry.Expires.Before(mark.Value.(seenKey).Expires) {
+			mark = mark.Prev()
+		}
+		if mark == nil {
+			remembered.expiry = dedupe.expiry.PushFront(entry)
+		} else {
+			remembered.expiry = dedupe.expiry.InsertAfter(entry, mark)
+		}
+	}
+	dedupe.seen[entry.Key] = remembered
+
+	for dedupe.options.MaxSize > 0 && dedupe.order.Len() > dedupe.options.MaxSize {
+		dedupe.forget(dedupe.order.Back().Value.(seenKey).Key)
+	}
+}
+
+// forget remove the key, must be called with the lock held
+func (dedupe *dedupeProcessor) forget(key string) {
+	remembered, ok := dedupe.seen[key]
+	if !ok {
+		return
+	}
+	dedupe.order.Remove(remembered.order)
+	if remembered.expiry != nil {
+		dedupe.expiry.Remove(remembered.expiry)
+	}
+	delete(dedupe.seen, key)
+}
+
+// save append the key to the file, the file is compacted when it holds many forgotten keys
+func (dedupe *dedupeProcessor) save(entry seenKey) error {
+	dedupe.fileLock.Lock()
+	defer dedupe.fileLock.Unlock()
+
+	dedupe.Lock()
+	compact := dedupe.logged >= dedupeCompactThreshold && dedupe.logged >= 2*dedupe.order.Len()
+	dedupe.Unlock()
+
+	if compact {
+		return dedupe.compact()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(dedupe.options.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	dedupe.Lock()
+	dedupe.logged++
+	dedupe.Unlock()
+
+	return nil
+}
+
+// compact rewrite the file with the keys not expired, must be called with the file lock held
+func (dedupe *dedupeProcessor) compact() error {
+	now := time.Now()
+
+	dedupe.Lock()
+	entries := []seenKey{}
+	for elem := dedupe.order.Back(); elem != nil; elem = elem.Prev() {
+		entry := elem.Value.(seenKey)
+		if entry.Expires.IsZero() || now.Before(entry.Expires) {
+			entries = append(entries, entry)
+		}
+	}
+	dedupe.Unlock()
+
+	data := []byte{}
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	tmp := dedupe.options.Path + ".tmp"
+	err := ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp, dedupe.options.Path)
+	if err != nil {
+		return err
+	}
+
+	dedupe.Lock()
+	dedupe.logged = len(entries)
+	dedupe.Unlock()
+
+	return nil
+}
+
+// load read the keys appended to the file, the last occurrence of a key is the most recent
+func (dedupe *dedupeProcessor) load() error {
+	file, err := os.Open(dedupe.options.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	now := time.Now()
+	decoder := json.NewDecoder(file)
+	for {
+		entry := seenKey{}
+		err = decoder.Decode(&entry)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		dedupe.logged++
+		if entry.Expires.IsZero() || now.Before(entry.Expires) {
+			dedupe.remember(entry)
+		}
+	}
+}
+
+// Dedupe the dedupe operator type
+type Dedupe struct {
+	Node
+}
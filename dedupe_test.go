@@ -0,0 +1,138 @@
+package collargo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func payloadValues(signals []Signal, name string) []interface{} {
+	values := []interface{}{}
+	for _, s := range signals {
+		v, _ := s.Get(name)
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestDedupe(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+	dedupe := input.Dedupe("dedupe", KeyByPayload("id"), DedupeOptions{
+		TTL: 50 * time.Millisecond,
+	})
+	assert.Equal(t, "dedupe", dedupe.Type())
+
+	sent := collectSignals(dedupe)
+
+	for _, id := range []int{1, 2, 1, 3, 2} {
+		input.Push(map[string]interface{}{"id": id})
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, payloadValues(sent(), "id"))
+
+	// signals without key are never dropped
+	input.Push(map[string]interface{}{"name": "a"})
+	input.Push(map[string]interface{}{"name": "b"})
+	assert.Equal(t, 5, len(sent()))
+	sent = collectSignals(dedupe)
+
+	// keys expire
+	time.Sleep(60 * time.Millisecond)
+	input.Push(map[string]interface{}{"id": 1})
+	assert.Equal(t, []interface{}{1}, payloadValues(sent(), "id"))
+}
+
+func TestDedupeMaxSize(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	dedupe := ns.Distinct("dedupe", KeyByPayload(AnonPayload), DedupeOptions{
+		MaxSize: 2,
+	})
+
+	sent := collectSignals(dedupe)
+
+	// 1 is forgotten when 3 is seen, 2 is kept as it was seen again
+	for _, v := range []int{1, 2, 2, 3, 2, 1} {
+		dedupe.Push(v)
+	}
+	assert.Equal(t, []interface{}{1, 2, 3, 1}, payloadValues(sent(), AnonPayload))
+}
+
+func TestDedupePurgeExpired(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	dedupe := ns.Dedupe("dedupe", KeyByPayload(AnonPayload), DedupeOptions{
+		TTL: 20 * time.Millisecond,
+	})
+	processor := dedupe.SignalProcessor().(*dedupeProcessor)
+
+	for i := 0; i < 100; i++ {
+		dedupe.Push(i)
+	}
+	assert.Equal(t, 100, len(processor.seen))
+
+	// the expired keys are forgotten when a new key is seen, not only when they come back
+	time.Sleep(30 * time.Millisecond)
+	dedupe.Push(100)
+	assert.Equal(t, 1, len(processor.seen))
+	assert.Equal(t, 1, processor.order.Len())
+	assert.Equal(t, 1, processor.expiry.Len())
+}
+
+func TestDedupeWithoutKey(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	assert.Panics(t, func() { ns.Dedupe("dedupe", nil, DedupeOptions{}) })
+}
+
+func TestDedupePersistence(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	dir, err := ioutil.TempDir("", "collargo")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	options := DedupeOptions{
+		Path: filepath.Join(dir, "seen.json"),
+	}
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	dedupe := ns.Dedupe("dedupe", KeyByPayload(AnonPayload), options)
+	dedupe.Push("a")
+	dedupe.Push("b")
+
+	// a new operator remembers the keys seen before
+	restored := ns.Dedupe("dedupe", KeyByPayload(AnonPayload), options)
+	sent := collectSignals(restored)
+	restored.Push("a")
+	restored.Push("c")
+	assert.Equal(t, []interface{}{"c"}, payloadValues(sent(), AnonPayload))
+}
+
+func TestDedupeCompaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collargo")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	options := DedupeOptions{
+		MaxSize: 10,
+		Path:    filepath.Join(dir, "seen.json"),
+	}
+
+	dedupe := newDedupeProcessor(KeyByPayload(AnonPayload), options)
+	for i := 0; i < dedupeCompactThreshold+1; i++ {
+		dedupe.OnSignal(CreateSignal(i), func(s Signal) {})
+	}
+	assert.Equal(t, 10, dedupe.logged)
+
+	restored := newDedupeProcessor(KeyByPayload(AnonPayload), options)
+	assert.Equal(t, 10, restored.order.Len())
+	_, seen := restored.seen["1000"]
+	assert.True(t, seen)
+}
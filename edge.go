@@ -0,0 +1,88 @@
+package collargo
+
+import (
+	"github.com/satori/go.uuid"
+	"strings"
+)
+
+// EdgeFilter decide whether a signal goes through an edge
+type EdgeFilter func(s Signal) bool
+
+// EdgeTransform transform the signals going through an edge
+type EdgeTransform func(s Signal) Signal
+
+// EdgeOptions the options used to connect two nodes
+type EdgeOptions struct {
+	Label     string
+	Tags      []string
+	Filter    EdgeFilter    // only the signals accepted by the filter go through the edge, all of them if nil
+	Transform EdgeTransform // applied to the signals going through the edge, after the filter
+}
+
+// Edge the connection between a node and one of its downstream nodes
+type Edge struct {
+	ID        string
+	Label     string
+	Tags      []string
+	Source    Node
+	Target    Node
+	Filter    EdgeFilter
+	Transform EdgeTransform
+}
+
+// newEdge create an edge from the source node to the target node
+func newEdge(source Node, target Node, options EdgeOptions) *Edge {
+	tags := options.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return &Edge{
+		ID:        uuid.NewV4().String(),
+		Label:     options.Label,
+		Tags:      tags,
+		Source:    source,
+		Target:    target,
+		Filter:    options.Filter,
+		Transform: options.Transform,
+	}
+}
+
+// parseEdgeOptions get the edge label and tags from the comment of To
+func parseEdgeOptions(comment string) EdgeOptions {
+	_, tags, label := parseInfoFromComment(comment)
+	return EdgeOptions{
+		Label: strings.TrimSpace(label),
+		Tags:  tags,
+	}
+}
+
+// Options get the options the edge was created with
+func (edge *Edge) Options() EdgeOptions {
+	return EdgeOptions{
+		Label:     edge.Label,
+		Tags:      edge.Tags,
+		Filter:    edge.Filter,
+		Transform: edge.Transform,
+	}
+}
+
+// deliver push the signal to the target node if it goes through the edge
+func (edge *Edge) deliver(s Signal) {
+	if edge.Filter != nil && !edge.Filter(s) {
+		return
+	}
+	if edge.Transform != nil {
+		s = edge.Transform(s)
+	}
+	edge.Target.Push(s)
+}
+
+// copyEdgeSet copy a set of edges
+func copyEdgeSet(edges map[string]*Edge) map[string]*Edge {
+	copied := make(map[string]*Edge, len(edges))
+	for id, edge := range edges {
+		copied[id] = edge
+	}
+	return copied
+}
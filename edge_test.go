@@ -0,0 +1,99 @@
+package collargo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEdgeFromComment(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+	output := ns.Output("output")
+
+	var observed *Edge
+	input.Observe(func(node Node, when string, s Signal, data ...interface{}) error {
+		if when == "to" {
+			observed = data[1].(*Edge)
+		}
+		return nil
+	})
+
+	input.To("valid orders #orders #checked", output)
+
+	edge, ok := input.Edges()[output.ID()]
+	assert.True(t, ok)
+	assert.Equal(t, edge, observed)
+	assert.NotEqual(t, "", edge.ID)
+	assert.Equal(t, "valid orders", edge.Label)
+	assert.Equal(t, []string{"orders", "checked"}, edge.Tags)
+	assert.Equal(t, input.ID(), edge.Source.ID())
+	assert.Equal(t, output.ID(), edge.Target.ID())
+}
+
+func TestOperatorEdge(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+	double := input.Map("@double #debug multiply by 2", func(s Signal) (Signal, error) {
+		return s, nil
+	})
+
+	// the comment describes the node, not the edge
+	edge := input.Edges()[double.ID()]
+	assert.Equal(t, "", edge.Label)
+	assert.Equal(t, 0, len(edge.Tags))
+	assert.Equal(t, []string{"debug"}, double.Tags())
+}
+
+func TestEdgeFilterAndTransform(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+	all := ns.Output("all")
+	even := ns.Output("even")
+
+	input.To("all", all)
+	input.Connect(even, EdgeOptions{
+		Label: "even x10",
+		Filter: func(s Signal) bool {
+			v, _ := s.Get(AnonPayload)
+			return v.(int)%2 == 0
+		},
+		Transform: func(s Signal) Signal {
+			v, _ := s.Get(AnonPayload)
+			return s.New(v.(int) * 10)
+		},
+	})
+
+	allSent := collectSignals(all)
+	evenSent := collectSignals(even)
+
+	for i := 1; i <= 4; i++ {
+		input.Push(i)
+	}
+
+	assert.Equal(t, []interface{}{1, 2, 3, 4}, payloadValues(allSent(), AnonPayload))
+	assert.Equal(t, []interface{}{20, 40}, payloadValues(evenSent(), AnonPayload))
+}
+
+func TestReplaceKeepsEdges(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+	old := ns.Output("old")
+	output := ns.Output("output")
+	replacement := ns.Output("replacement")
+
+	input.To("to old #a", old)
+	old.To("to output #b", output)
+
+	Collar.Replace(old, replacement)
+
+	edge := input.Edges()[replacement.ID()]
+	assert.Equal(t, "to old", edge.Label)
+	assert.Equal(t, []string{"a"}, edge.Tags)
+
+	edge = replacement.Edges()[output.ID()]
+	assert.Equal(t, "to output", edge.Label)
+	assert.Equal(t, []string{"b"}, edge.Tags)
+	assert.Equal(t, 0, len(old.Edges()))
+}
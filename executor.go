@@ -1,5 +1,9 @@
 package collargo
 
+import (
+	"sync"
+)
+
 // Executable the executable function type
 type Executable func(s Signal, send SendSignalFunc) error
 
@@ -11,25 +15,145 @@ type Executor interface {
 	Execute()
 }
 
+// Dispatcher an optional executor extension deciding how a signal is delivered to a downstream node,
+// with executors not implementing it each signal is delivered in a new goroutine
+type Dispatcher interface {
+	// Dispatch run or queue the delivery of a signal
+	Dispatch(deliver func())
+}
+
 type defaultExecutor struct {
 }
 
 func (executor defaultExecutor) Schedule(executable Executable, node Node, s Signal) {
+	go execute(executable, node, s)
+}
+
+func (executor defaultExecutor) Execute() {
+	// do nothing
+}
+
+// SyncExecutor the executor running everything inline: a signal pushed to a node is processed
+// by the whole graph before Push returns, which makes graph tests deterministic
+type SyncExecutor struct {
+}
+
+// CreateSyncExecutor create a synchronous executor
+func CreateSyncExecutor() *SyncExecutor {
+	return &SyncExecutor{}
+}
+
+// Schedule run the executable inline
+func (executor *SyncExecutor) Schedule(executable Executable, node Node, s Signal) {
+	execute(executable, node, s)
+}
+
+// Execute do nothing, everything is executed when scheduled
+func (executor *SyncExecutor) Execute() {
+	// do nothing
+}
+
+// Dispatch deliver the signal inline
+func (executor *SyncExecutor) Dispatch(deliver func()) {
+	deliver()
+}
+
+// ManualExecutor the executor queuing the scheduled executables and deliveries,
+// they are only run when stepped, on the goroutine calling RunOne or RunUntilIdle
+type ManualExecutor struct {
+	sync.Mutex
+	tasks []func()
+}
+
+// CreateManualExecutor create a manual executor
+func CreateManualExecutor() *ManualExecutor {
+	return &ManualExecutor{
+		tasks: []func(){},
+	}
+}
+
+// Schedule queue the executable
+func (executor *ManualExecutor) Schedule(executable Executable, node Node, s Signal) {
+	executor.queue(func() {
+		execute(executable, node, s)
+	})
+}
+
+// Execute run until idle
+func (executor *ManualExecutor) Execute() {
+	executor.RunUntilIdle()
+}
+
+// Dispatch queue the delivery of the signal
+func (executor *ManualExecutor) Dispatch(deliver func()) {
+	executor.queue(deliver)
+}
+
+// Pending get the number of queued tasks
+func (executor *ManualExecutor) Pending() int {
+	executor.Lock()
+	defer executor.Unlock()
+	return len(executor.tasks)
+}
+
+// RunOne run the oldest queued task, returns false if there is nothing to run
+func (executor *ManualExecutor) RunOne() bool {
+	executor.Lock()
+	if len(executor.tasks) == 0 {
+		executor.Unlock()
+		return false
+	}
+	task := executor.tasks[0]
+	executor.tasks = executor.tasks[1:]
+	executor.Unlock()
+
+	task()
+	return true
+}
+
+// RunUntilIdle run the queued tasks, and the tasks they queue, until there is nothing left,
+// returns the number of tasks run
+func (executor *ManualExecutor) RunUntilIdle() int {
+	count := 0
+	for executor.RunOne() {
+		count++
+	}
+	return count
+}
+
+func (executor *ManualExecutor) queue(task func()) {
+	executor.Lock()
+	executor.tasks = append(executor.tasks, task)
+	executor.Unlock()
+}
+
+// dispatch deliver a signal with the dispatcher of the current executor, or in a new goroutine
+func dispatch(deliver func()) {
+	if dispatcher, ok := Collar.GetExecutor().(Dispatcher); ok {
+		dispatcher.Dispatch(deliver)
+		return
+	}
+	go deliver()
+}
+
+// execute run the executable with a signal, the error it returns is sent as an error signal
+func execute(executable Executable, node Node, s Signal) {
 	send := func(signal Signal) {
 		node.Send(signal)
 	}
 
-	run := func() {
-		err := executable(s, send)
+	err := executable(s, send)
 
-		if err != nil {
-			errSignal := s.SetError(err)
-			send(errSignal)
-		}
+	if err != nil {
+		errSignal := s.SetError(err)
+		send(errSignal)
 	}
-	go run()
-}
 
-func (executor defaultExecutor) Execute() {
-	// do nothing
+	// let the observers know the node is done with the signal
+	if !Collar.trackingActivity() {
+		return
+	}
+	if n := concreteNode(node); n != nil {
+		n.notify("done", s)
+	}
 }
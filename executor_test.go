@@ -0,0 +1,109 @@
+package collargo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// useExecutor use the executor in a test, the returned function restores the previous one
+func useExecutor(executor Executor) func() {
+	previous := Collar.GetExecutor()
+	Collar.SetExecutor(executor)
+	return func() {
+		Collar.SetExecutor(previous)
+	}
+}
+
+func TestSyncExecutor(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	input := ns.Input("input")
+
+	results := []int{}
+	input.Map("x2", func(s Signal) (Signal, error) {
+		v, _ := s.Get(AnonPayload)
+		return s.New(v.(int) * 2), nil
+	}).Map("+1", func(s Signal) (Signal, error) {
+		v, _ := s.Get(AnonPayload)
+		return s.New(v.(int) + 1), nil
+	}).Do("collect", func(s Signal) (interface{}, error) {
+		v, _ := s.Get(AnonPayload)
+		results = append(results, v.(int))
+		return nil, nil
+	})
+
+	input.Push(1)
+	input.Push(10)
+	input.Push(5)
+
+	// no need to wait, signals are processed when pushed
+	assert.Equal(t, []int{3, 21, 11}, results)
+
+	output := ns.Output("output")
+	input.Map("fail", func(s Signal) (Signal, error) {
+		return s, errors.New("failed")
+	}).To("output", output)
+
+	flowFunc := Collar.ToFlowFunc(input, output)
+	_, err := flowFunc(1)
+	assert.Equal(t, "failed", err.Error())
+}
+
+func TestManualExecutor(t *testing.T) {
+	executor := CreateManualExecutor()
+	defer useExecutor(executor)()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	input := ns.Input("input")
+
+	results := []int{}
+	input.Map("x2", func(s Signal) (Signal, error) {
+		v, _ := s.Get(AnonPayload)
+		return s.New(v.(int) * 2), nil
+	}).Do("collect", func(s Signal) (interface{}, error) {
+		v, _ := s.Get(AnonPayload)
+		results = append(results, v.(int))
+		return nil, nil
+	})
+
+	input.Push(1)
+	input.Push(2)
+	assert.Equal(t, 0, len(results))
+	assert.Equal(t, 2, executor.Pending())
+
+	assert.True(t, executor.RunOne())
+	assert.Equal(t, 0, len(results))
+
+	assert.True(t, executor.RunUntilIdle() > 0)
+	assert.Equal(t, []int{2, 4}, results)
+	assert.Equal(t, 0, executor.Pending())
+	assert.False(t, executor.RunOne())
+}
+
+func TestSyncExecutorOrderedNode(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	input := ns.Input("input")
+
+	results := []int{}
+	input.Map("x2", func(s Signal) (Signal, error) {
+		v, _ := s.Get(AnonPayload)
+		return s.New(v.(int) * 2), nil
+	}).Ordered().To("collect", ns.Do("collect", func(s Signal) (interface{}, error) {
+		v, _ := s.Get(AnonPayload)
+		results = append(results, v.(int))
+		return nil, nil
+	}))
+
+	for i := 0; i < 5; i++ {
+		input.Push(i)
+	}
+
+	assert.Equal(t, []int{0, 2, 4, 6, 8}, results)
+}
@@ -0,0 +1,387 @@
+package collargo
+
+import (
+	"bufio"
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultFilePollInterval the interval a file tail sensor checks for new lines when the option is 0
+const DefaultFilePollInterval = 250 * time.Millisecond
+
+// FileSensorOptions the options of the file sensors
+type FileSensorOptions struct {
+	// Checkpoint the store keeping the progress to resume from after a restart, no checkpoint if nil
+	Checkpoint StateStore
+	// FromStart without checkpoint, tail the file from its start instead of its end,
+	// or send the existing files of the watched directory
+	FromStart bool
+	// PollInterval the interval a file tail sensor checks for new lines and rotations
+	PollInterval time.Duration
+}
+
+/**
+ * File tail sensor
+ */
+
+// FileTailSensor the sensor tailing a file once started, following its rotations and truncations:
+// it sends a signal for each line, with the line ("line"), the file path ("path") and the offset
+// after the line ("offset") as payload
+type FileTailSensor struct {
+	Sensor
+	path    string
+	options FileSensorOptions
+}
+
+// createFileTailSensor create a file tail sensor
+func createFileTailSensor(ns Namespace, comment string, path string, options FileSensorOptions) *FileTailSensor {
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultFilePollInterval
+	}
+
+	tail := &FileTailSensor{
+		path:    path,
+		options: options,
+	}
+
+	tail.Sensor = ns.Sensor(comment, tail.watch, true)
+	tail.SetType("sensor.file")
+	tail.AddMeta("path", path)
+
+	return tail
+}
+
+// Start start to tail the file
+func (tail *FileTailSensor) Start() *FileTailSensor {
+	tail.Watch(SensorOptions{"path": tail.path})
+	return tail
+}
+
+// wait wait for the poll interval, returns false if the sensor is stopped
+func (tail *FileTailSensor) wait(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(tail.options.PollInterval):
+		return true
+	}
+}
+
+// fileReader the reader of the tailed file, the offset is the one of the last complete line
+type fileReader struct {
+	path    string
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64
+	partial string
+}
+
+// open open the tailed file at the checkpoint offset, at its start or its end, waiting for it to exist
+func (tail *FileTailSensor) open(ctx context.Context, path string, first bool) (*fileReader, bool) {
+	for {
+		file, err := os.Open(path)
+		if err == nil {
+			offset := int64(0)
+			if first {
+				offset = tail.startOffset(path, file)
+			}
+			file.Seek(offset, io.SeekStart)
+			return &fileReader{
+				path:   path,
+				file:   file,
+				reader: bufio.NewReader(file),
+				offset: offset,
+			}, true
+		}
+
+		if !tail.wait(ctx) {
+			return nil, false
+		}
+	}
+}
+
+// startOffset get the checkpoint offset, or the offset of the start or the end of the file
+func (tail *FileTailSensor) startOffset(path string, file *os.File) int64 {
+	info, err := file.Stat()
+	if err != nil {
+		return 0
+	}
+
+	if tail.options.Checkpoint != nil {
+		value, existed, err := tail.options.Checkpoint.Get(path)
+		if offset, ok := checkpointOffset(value); existed && err == nil && ok {
+			// the file was truncated or rotated since the checkpoint
+			if offset > info.Size() {
+				return 0
+			}
+			return offset
+		}
+	}
+
+	if tail.options.FromStart {
+		return 0
+	}
+	return info.Size()
+}
+
+// checkpointOffset get the offset saved in a checkpoint, decoded as a float64 by the file state store
+func checkpointOffset(value interface{}) (int64, bool) {
+	switch offset := value.(type) {
+	case int64:
+		return offset, true
+	case int:
+		return int64(offset), true
+	case float64:
+		return int64(offset), true
+	}
+	return 0, false
+}
+
+// readLines send the complete lines until the end of the file
+func (tail *FileTailSensor) readLines(r *fileReader, send SendDataFunc) error {
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err == io.EOF {
+			// the line is sent once it is complete
+			r.partial += line
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = r.partial + line
+		r.partial = ""
+		r.offset += int64(len(line))
+
+		send(CreateSignal(map[string]interface{}{
+			"line":   strings.TrimRight(line, "\r\n"),
+			"path":   r.path,
+			"offset": r.offset,
+		}))
+	}
+}
+
+// watch send the lines of the file of the options until the sensor is stopped
+func (tail *FileTailSensor) watch(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+	path, ok := options["path"].(string)
+	if !ok {
+		path = tail.path
+	}
+
+	r, ok := tail.open(ctx, path, true)
+	if !ok {
+		return nil
+	}
+	defer func() {
+		r.file.Close()
+	}()
+
+	for {
+		if err := tail.readLines(r, send); err != nil {
+			send(err)
+		}
+
+		if tail.options.Checkpoint != nil {
+			if err := tail.options.Checkpoint.Set(path, r.offset); err != nil {
+				send(err)
+			}
+		}
+
+		if !tail.wait(ctx) {
+			return nil
+		}
+
+		current, err := os.Stat(path)
+		if err != nil {
+			// the file is being rotated
+			continue
+		}
+		opened, err := r.file.Stat()
+		if err != nil {
+			continue
+		}
+
+		if !os.SameFile(current, opened) {
+			// rotated: the end of the previous file is read before switching to the new one
+			if err := tail.readLines(r, send); err != nil {
+				send(err)
+			}
+			r.file.Close()
+
+			r, ok = tail.open(ctx, path, false)
+			if !ok {
+				return nil
+			}
+			continue
+		}
+
+		if current.Size() < r.offset+int64(len(r.partial)) {
+			// truncated: read again from the start
+			r.file.Seek(0, io.SeekStart)
+			r.reader.Reset(r.file)
+			r.offset = 0
+			r.partial = ""
+		}
+	}
+}
+
+/**
+ * Directory watch sensor
+ */
+
+// DirWatchSensor the sensor watching the files created or modified in a directory once started:
+// it sends a signal for each event, with the file path ("path"), the file name ("name") and the event type
+// ("event", "create" or "write") as payload
+type DirWatchSensor struct {
+	Sensor
+	dir     string
+	options FileSensorOptions
+}
+
+// createDirWatchSensor create a directory watch sensor
+func createDirWatchSensor(ns Namespace, comment string, dir string, options FileSensorOptions) *DirWatchSensor {
+	watcher := &DirWatchSensor{
+		dir:     dir,
+		options: options,
+	}
+
+	watcher.Sensor = ns.Sensor(comment, watcher.watch, true)
+	watcher.SetType("sensor.dir")
+	watcher.AddMeta("path", dir)
+
+	return watcher
+}
+
+// Start start to watch the directory
+func (watcher *DirWatchSensor) Start() *DirWatchSensor {
+	watcher.Watch(SensorOptions{"dir": watcher.dir})
+	return watcher
+}
+
+// watch send the events of the directory of the options until the sensor is stopped
+func (watcher *DirWatchSensor) watch(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+	dir, ok := options["dir"].(string)
+	if !ok {
+		dir = watcher.dir
+	}
+
+	notifier, err := fsnotify.NewWatcher()
+	if err != nil {
+		send(err)
+		return err
+	}
+	defer notifier.Close()
+
+	if err := notifier.Add(dir); err != nil {
+		send(err)
+		return err
+	}
+
+	watcher.resume(dir, send)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event := <-notifier.Events:
+			switch {
+			case event.Op&fsnotify.Create == fsnotify.Create:
+				watcher.send("create", event.Name, send)
+			case event.Op&fsnotify.Write == fsnotify.Write:
+				watcher.send("write", event.Name, send)
+			}
+
+		case err := <-notifier.Errors:
+			send(err)
+		}
+	}
+}
+
+// dirCheckpointKey the checkpoint key marking that the directory was already watched, it is never a file name
+const dirCheckpointKey = "."
+
+// resume send the files created or modified since the checkpoint, or all the files if FromStart
+//
+// the first time a directory is watched with a checkpoint, the existing files are only recorded unless FromStart
+func (watcher *DirWatchSensor) resume(dir string, send SendDataFunc) {
+	checkpoint := watcher.options.Checkpoint
+	if checkpoint == nil && !watcher.options.FromStart {
+		return
+	}
+
+	baseline := false
+	if checkpoint != nil {
+		_, watched, _ := checkpoint.Get(dirCheckpointKey)
+		baseline = !watched && !watcher.options.FromStart
+		if err := checkpoint.Set(dirCheckpointKey, time.Now().Format(time.RFC3339Nano)); err != nil {
+			send(err)
+		}
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		send(err)
+		return
+	}
+
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+
+		event := "create"
+		if checkpoint != nil {
+			value, existed, _ := checkpoint.Get(info.Name())
+			if seen, ok := value.(string); existed && ok {
+				seenTime, _ := time.Parse(time.RFC3339Nano, seen)
+				if !info.ModTime().After(seenTime) {
+					continue
+				}
+				event = "write"
+			}
+		}
+
+		if baseline {
+			watcher.record(info, send)
+			continue
+		}
+
+		watcher.send(event, filepath.Join(dir, info.Name()), send)
+	}
+}
+
+// record save the modification time of a file in the checkpoint
+func (watcher *DirWatchSensor) record(info os.FileInfo, send SendDataFunc) {
+	if watcher.options.Checkpoint == nil {
+		return
+	}
+	err := watcher.options.Checkpoint.Set(info.Name(), info.ModTime().Format(time.RFC3339Nano))
+	if err != nil {
+		send(err)
+	}
+}
+
+// send send the event of a file and save its modification time in the checkpoint
+func (watcher *DirWatchSensor) send(event string, path string, send SendDataFunc) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		// removed since the event, or not a file
+		return
+	}
+
+	watcher.record(info, send)
+
+	send(CreateSignal(map[string]interface{}{
+		"path":  path,
+		"name":  info.Name(),
+		"event": event,
+	}))
+}
@@ -0,0 +1,195 @@
+package collargo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const fileTestDelay = 100 * time.Millisecond
+
+func appendFile(t *testing.T, path string, content string) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	file.WriteString(content)
+	file.Close()
+}
+
+func TestTailFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collargo")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "old line\n")
+
+	checkpoint := CreateMemoryStateStore()
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	tail := ns.TailFile("tail", path, FileSensorOptions{
+		Checkpoint:   checkpoint,
+		PollInterval: 10 * time.Millisecond,
+	})
+	assert.Equal(t, "sensor.file", tail.Type())
+	sent := collectSignals(tail)
+
+	tail.Start()
+	time.Sleep(fileTestDelay)
+
+	appendFile(t, path, "first\nsec")
+	time.Sleep(fileTestDelay)
+	appendFile(t, path, "ond\nlast before rotation\n")
+
+	// rotate
+	assert.Nil(t, os.Rename(path, path+".1"))
+	appendFile(t, path, "after rotation\n")
+	time.Sleep(fileTestDelay)
+
+	// truncate
+	assert.Nil(t, ioutil.WriteFile(path, []byte("truncated\n"), 0644))
+	time.Sleep(fileTestDelay)
+
+	tail.Stop()
+	time.Sleep(fileTestDelay)
+
+	assert.Equal(t, []interface{}{"first", "second", "last before rotation", "after rotation", "truncated"},
+		payloadValues(sent(), "line"))
+
+	offset, _, _ := checkpoint.Get(path)
+	assert.Equal(t, int64(len("truncated\n")), offset)
+
+	// resume from the checkpoint
+	appendFile(t, path, "while stopped\n")
+	resumed := ns.TailFile("tail", path, FileSensorOptions{
+		Checkpoint:   checkpoint,
+		PollInterval: 10 * time.Millisecond,
+	})
+	resumedSent := collectSignals(resumed)
+	resumed.Start()
+	time.Sleep(fileTestDelay)
+	resumed.Stop()
+
+	assert.Equal(t, []interface{}{"while stopped"}, payloadValues(resumedSent(), "line"))
+}
+
+func TestTailFileFromStart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collargo")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	tail := ns.TailFile("tail", path, FileSensorOptions{
+		FromStart:    true,
+		PollInterval: 10 * time.Millisecond,
+	})
+	sent := collectSignals(tail)
+
+	// the file does not exist yet
+	tail.Start()
+	time.Sleep(fileTestDelay)
+	appendFile(t, path, "one\ntwo\n")
+	time.Sleep(fileTestDelay)
+	tail.Stop()
+
+	assert.Equal(t, []interface{}{"one", "two"}, payloadValues(sent(), "line"))
+}
+
+func TestWatchDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collargo")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	appendFile(t, filepath.Join(dir, "existing.csv"), "existing\n")
+
+	checkpoint := CreateMemoryStateStore()
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	watcher := ns.WatchDir("watch", dir, FileSensorOptions{Checkpoint: checkpoint})
+	assert.Equal(t, "sensor.dir", watcher.Type())
+	sent := collectSignals(watcher)
+
+	watcher.Start()
+	time.Sleep(fileTestDelay)
+	appendFile(t, filepath.Join(dir, "new.csv"), "new\n")
+	time.Sleep(fileTestDelay)
+	watcher.Stop()
+	time.Sleep(fileTestDelay)
+
+	signals := sent()
+	assert.True(t, len(signals) >= 1)
+	assert.Equal(t, "create", payloadValues(signals, "event")[0])
+	for _, s := range signals {
+		name, _ := s.Get("name")
+		assert.Equal(t, "new.csv", name)
+		path, _ := s.Get("path")
+		assert.Equal(t, filepath.Join(dir, "new.csv"), path)
+	}
+
+	// the files created while stopped are sent when resuming
+	appendFile(t, filepath.Join(dir, "offline.csv"), "offline\n")
+	resumed := ns.WatchDir("watch", dir, FileSensorOptions{Checkpoint: checkpoint})
+	resumedSent := collectSignals(resumed)
+	resumed.Start()
+	time.Sleep(fileTestDelay)
+	resumed.Stop()
+
+	assert.Equal(t, []interface{}{"offline.csv"}, payloadValues(resumedSent(), "name"))
+	assert.Equal(t, []interface{}{"create"}, payloadValues(resumedSent(), "event"))
+}
+
+func TestWatchDirFromStart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collargo")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	appendFile(t, filepath.Join(dir, "existing.csv"), "existing\n")
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	watcher := ns.WatchDir("watch", dir, FileSensorOptions{FromStart: true})
+	sent := collectSignals(watcher)
+	watcher.Start()
+	time.Sleep(fileTestDelay)
+	watcher.Stop()
+
+	assert.Equal(t, []interface{}{"existing.csv"}, payloadValues(sent(), "name"))
+
+	missing := ns.WatchDir("watch", filepath.Join(dir, "missing"), FileSensorOptions{})
+	missingSent := collectSignals(missing)
+	missing.Start()
+	time.Sleep(fileTestDelay)
+	assert.Equal(t, 1, len(missingSent()))
+	assert.NotNil(t, missingSent()[0].Error)
+}
+
+func TestFileSensorsOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collargo")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	other := filepath.Join(dir, "other")
+	assert.Nil(t, os.Mkdir(other, 0755))
+	appendFile(t, filepath.Join(other, "other.csv"), "other\n")
+
+	// the watched directory and the tailed file are the ones of the options
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	watcher := ns.WatchDir("watch", dir, FileSensorOptions{FromStart: true})
+	watcherSent := collectSignals(watcher)
+	watcher.Watch(SensorOptions{"dir": other})
+
+	tail := ns.TailFile("tail", filepath.Join(dir, "missing.log"), FileSensorOptions{
+		FromStart:    true,
+		PollInterval: 10 * time.Millisecond,
+	})
+	tailSent := collectSignals(tail)
+	tail.Watch(SensorOptions{"path": filepath.Join(other, "other.csv")})
+
+	time.Sleep(fileTestDelay)
+	watcher.Stop()
+	tail.Stop()
+
+	assert.Equal(t, []interface{}{"other.csv"}, payloadValues(watcherSent(), "name"))
+	assert.Equal(t, []interface{}{"other"}, payloadValues(tailSent(), "line"))
+}
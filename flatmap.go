@@ -0,0 +1,161 @@
+package collargo
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchPayload the payload name holding the payloads of the signals grouped by the batch operator
+const BatchPayload string = "__batch__"
+
+/**
+ * FlatMap Operator callback
+ */
+
+// FlatMapCallback the callback function for flatmap operator, returns the signals to send
+type FlatMapCallback func(s Signal) ([]Signal, error)
+
+/**
+ * Signal Processor for flatmap operator
+ */
+
+type flatMapProcessor struct {
+	process FlatMapCallback
+}
+
+func (processor flatMapProcessor) OnError(s Signal, send SendSignalFunc) error {
+	send(s)
+	return nil
+}
+
+func (processor flatMapProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	signals, err := processor.process(s)
+
+	if err != nil {
+		return err
+	}
+
+	// each signal sent is a child of the processed signal
+	for _, signal := range signals {
+		send(s.Child(signal))
+	}
+	return nil
+}
+
+// FlatMap the flatmap operator
+type FlatMap struct {
+	Node
+}
+
+/**
+ * Signal Processor for batch operator
+ */
+
+type batchProcessor struct {
+	sync.Mutex
+	size    int
+	maxWait time.Duration
+	pending []Signal
+	timer   *time.Timer
+	// incremented by each flush, a timer only flushes the batch it was started for
+	generation int
+}
+
+func (batch *batchProcessor) OnError(s Signal, send SendSignalFunc) error {
+	send(s)
+	return nil
+}
+
+func (batch *batchProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	batch.Lock()
+	batch.pending = append(batch.pending, s)
+
+	if len(batch.pending) < batch.size && !s.End {
+		if len(batch.pending) == 1 && batch.maxWait > 0 {
+			generation := batch.generation
+			batch.timer = time.AfterFunc(batch.maxWait, func() {
+				batch.Lock()
+				if batch.generation != generation {
+					// the batch was flushed before the timer callback could run
+					batch.Unlock()
+					return
+				}
+				signals := batch.flush()
+				batch.Unlock()
+				if len(signals) > 0 {
+					send(group(signals))
+				}
+			})
+		}
+		batch.Unlock()
+		return nil
+	}
+
+	signals := batch.flush()
+	batch.Unlock()
+
+	send(group(signals))
+	return nil
+}
+
+// flush take the pending signals, must be called with the lock held
+func (batch *batchProcessor) flush() []Signal {
+	if batch.timer != nil {
+		batch.timer.Stop()
+		batch.timer = nil
+	}
+	batch.generation++
+	signals := batch.pending
+	batch.pending = []Signal{}
+	return signals
+}
+
+// group create the signal grouping the payloads of the signals, with the tags of the first one,
+// the grouped signal is a child of the parents of the signals (or of the signals without parent)
+// and derives from their origins
+func group(signals []Signal) Signal {
+	payloads := []map[string]interface{}{}
+	parents := []string{}
+	linked := map[string]bool{}
+	origins := []string{}
+	derived := map[string]bool{}
+	for _, s := range signals {
+		payloads = append(payloads, s.Payload)
+
+		parent, ok := s.GetTag(ParentTag)
+		if !ok {
+			parent = s.ID
+		}
+		for _, id := range strings.Split(parent, ",") {
+			if !linked[id] {
+				linked[id] = true
+				parents = append(parents, id)
+			}
+		}
+
+		for _, id := range strings.Split(s.origin(), ",") {
+			if !derived[id] {
+				derived[id] = true
+				origins = append(origins, id)
+			}
+		}
+	}
+
+	grouped := CreateSignal(map[string]interface{}{
+		BatchPayload: payloads,
+	})
+	for k, v := range signals[0].Tags {
+		grouped.Tags[k] = v
+	}
+	grouped.Tags[ParentTag] = strings.Join(parents, ",")
+	grouped.Tags[OriginTag] = strings.Join(origins, ",")
+	grouped.End = signals[len(signals)-1].End
+
+	return grouped
+}
+
+// Batch the batch operator
+type Batch struct {
+	Node
+}
@@ -0,0 +1,122 @@
+package collargo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFlatMap(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+
+	split := input.FlatMap("split", func(s Signal) ([]Signal, error) {
+		items, _ := s.Get("items")
+		if items == nil {
+			return nil, errors.New("no items")
+		}
+		if len(items.([]int)) == 0 {
+			return []Signal{s.SetError(errors.New("empty items"))}, nil
+		}
+		signals := []Signal{}
+		for _, item := range items.([]int) {
+			signals = append(signals, s.New(item))
+		}
+		return signals, nil
+	})
+	assert.Equal(t, "flatmap", split.Type())
+
+	sent := collectSignals(split)
+
+	parent := CreateSignal(map[string]interface{}{"items": []int{1, 2, 3}}).SetTag("source", "test")
+	input.Push(parent)
+	input.Push(map[string]interface{}{})
+	input.Push(map[string]interface{}{"items": []int{}})
+
+	signals := sent()
+	assert.Equal(t, 5, len(signals))
+	assert.Equal(t, []interface{}{1, 2, 3}, payloadValues(signals[:3], AnonPayload))
+	for _, s := range signals[:3] {
+		assert.NotEqual(t, parent.ID, s.ID)
+		id, _ := s.GetTag(ParentTag)
+		assert.Equal(t, parent.ID, id)
+		source, _ := s.GetTag("source")
+		assert.Equal(t, "test", source)
+	}
+	assert.Equal(t, "no items", signals[3].Error.Error())
+	// the error signals returned by the callback are kept
+	assert.Equal(t, "empty items", signals[4].Error.Error())
+}
+
+func TestBatch(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	batch := ns.Batch("batch", 2, 0)
+	assert.Equal(t, "batch", batch.Type())
+
+	sent := collectSignals(batch)
+
+	batch.Push(1)
+	assert.Equal(t, 0, len(sent()))
+	batch.Push(2)
+	batch.Push(3)
+
+	end := CreateSignal(4)
+	end.End = true
+	batch.Push(end)
+
+	signals := sent()
+	assert.Equal(t, 2, len(signals))
+	assert.Equal(t, []map[string]interface{}{{AnonPayload: 1}, {AnonPayload: 2}}, signals[0].Payload[BatchPayload])
+	assert.False(t, signals[0].End)
+	assert.Equal(t, []map[string]interface{}{{AnonPayload: 3}, {AnonPayload: 4}}, signals[1].Payload[BatchPayload])
+	assert.True(t, signals[1].End)
+}
+
+func TestBatchMaxWait(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	batch := ns.Batch("batch", 10, 50*time.Millisecond)
+
+	sent := collectSignals(batch)
+
+	batch.Push(1)
+	time.Sleep(10 * time.Millisecond)
+	batch.Push(2)
+	time.Sleep(100 * time.Millisecond)
+
+	signals := sent()
+	assert.Equal(t, 1, len(signals))
+	assert.Equal(t, 2, len(signals[0].Payload[BatchPayload].([]map[string]interface{})))
+}
+
+func TestBatchFlowFunc(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+	output := ns.Output("output")
+	input.Batch("batch", 2, 0).To("output", output)
+
+	flowFunc := Collar.ToFlowFunc(input, output)
+
+	// both calls are resolved with the batch grouping their signals
+	results := make(chan map[string]interface{}, 2)
+	for i := 0; i < 2; i++ {
+		go func(i int) {
+			result, err := flowFunc(i)
+			assert.Nil(t, err)
+			results <- result
+		}(i)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case result := <-results:
+			assert.Equal(t, 2, len(result[BatchPayload].([]map[string]interface{})))
+		case <-time.After(time.Second):
+			assert.Fail(t, "flow function not resolved")
+		}
+	}
+}
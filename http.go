@@ -0,0 +1,173 @@
+package collargo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPError an error with the http status code returned by a flow handler
+type HTTPError struct {
+	Status  int
+	Message string
+}
+
+// CreateHTTPError create an http error, the flows can return it to choose the response status
+func CreateHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{
+		Status:  status,
+		Message: message,
+	}
+}
+
+func (err *HTTPError) Error() string {
+	return err.Message
+}
+
+// DefaultMaxBodySize the default size limit of the request bodies, in bytes
+const DefaultMaxBodySize int64 = 1 << 20
+
+// FlowHandler the http handler invoking a flow: the json request body is the payload of the pushed signal,
+// and the payload reaching the output is the json response
+type FlowHandler struct {
+	flowFunc ContextFlowFunc
+	// Timeout the time limit of the flow when the request has no earlier deadline, no limit if 0
+	Timeout time.Duration
+	// MaxBodySize the size limit of the request body in bytes, DefaultMaxBodySize if 0
+	MaxBodySize int64
+}
+
+// CreateFlowHandler create an http handler for the flow between input and output
+func CreateFlowHandler(input Node, output Node) *FlowHandler {
+	return &FlowHandler{
+		flowFunc: Collar.ToContextFlowFunc(input, output),
+	}
+}
+
+// HandleFlow register the http handler of the flow between input and output for the route pattern
+func HandleFlow(mux *http.ServeMux, pattern string, input Node, output Node) *FlowHandler {
+	handler := CreateFlowHandler(input, output)
+	mux.Handle(pattern, handler)
+	return handler
+}
+
+func (handler *FlowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := decodeRequestBody(w, r, handler.MaxBodySize)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+	if handler.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, handler.Timeout)
+		defer cancel()
+	}
+
+	signal := CreateSignal(data).
+		SetTag("http.method", r.Method).
+		SetTag("http.path", r.URL.Path)
+
+	result, err := handler.flowFunc(ctx, signal)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// decodeRequestBody decode the json body, limited to maxSize bytes (DefaultMaxBodySize if 0):
+// a json object is the payload, other values are the anonymous payload. The errors are http errors
+func decodeRequestBody(w http.ResponseWriter, r *http.Request, maxSize int64) (interface{}, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBodySize
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSize))
+	if err != nil {
+		return nil, CreateHTTPError(http.StatusRequestEntityTooLarge,
+			"request body larger than "+strconv.FormatInt(maxSize, 10)+" bytes")
+	}
+	if len(body) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, CreateHTTPError(http.StatusBadRequest, "invalid json body: "+err.Error())
+	}
+	if data == nil {
+		return map[string]interface{}{}, nil
+	}
+	return data, nil
+}
+
+// httpStatus get the status code of a flow error
+func httpStatus(err error) int {
+	if httpErr, ok := err.(*HTTPError); ok {
+		return httpErr.Status
+	}
+	if IsCircuitOpen(err) {
+		return http.StatusServiceUnavailable
+	}
+
+	switch err {
+	case ErrRateLimited:
+		return http.StatusTooManyRequests
+	case context.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case context.Canceled:
+		// the client is gone, the response is not read
+		return http.StatusRequestTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// writeHTTPError write the structured error response, the message of the internal errors is not exposed
+func writeHTTPError(w http.ResponseWriter, err error) {
+	status := httpStatus(err)
+
+	if circuitErr, ok := err.(*CircuitOpenError); ok {
+		retry := time.Until(circuitErr.RetryAt).Seconds()
+		if retry < 1 {
+			retry = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(retry)))
+	}
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		if _, ok := err.(*HTTPError); !ok {
+			message = http.StatusText(status)
+		}
+	}
+
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"status":  status,
+			"message": message,
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		status = http.StatusInternalServerError
+		body, _ = json.Marshal(map[string]interface{}{
+			"error": map[string]interface{}{
+				"status":  status,
+				"message": "Failed to encode the response",
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
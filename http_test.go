@@ -0,0 +1,114 @@
+package collargo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createGreetingFlow() (Input, Output) {
+	ns := Collar.NS("com.collargo.test.http", map[string]string{})
+	input := ns.Input("input")
+	output := ns.Output("output")
+
+	input.Map("greet", func(s Signal) (Signal, error) {
+		name, ok := s.Get("name")
+		if !ok {
+			return s, CreateHTTPError(http.StatusUnprocessableEntity, "name is required")
+		}
+		if name == "slow" {
+			time.Sleep(testDelay * time.Millisecond)
+		}
+		method, _ := s.GetTag("http.method")
+		return s.New(map[string]interface{}{
+			"greeting": "Hello " + name.(string),
+			"method":   method,
+		}), nil
+	}).To("output", output)
+
+	return input, output
+}
+
+func serveFlow(handler http.Handler, body string) (int, map[string]interface{}) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/greet", strings.NewReader(body)))
+
+	var response map[string]interface{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder.Code, response
+}
+
+func TestFlowHandler(t *testing.T) {
+	input, output := createGreetingFlow()
+	mux := http.NewServeMux()
+	handler := HandleFlow(mux, "/greet", input, output)
+
+	status, response := serveFlow(mux, `{"name": "collar"}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Hello collar", response["greeting"])
+	assert.Equal(t, http.MethodPost, response["method"])
+
+	status, response = serveFlow(mux, `{}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, map[string]interface{}{
+		"status":  float64(http.StatusUnprocessableEntity),
+		"message": "name is required",
+	}, response["error"])
+
+	status, _ = serveFlow(mux, `{"name": `)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	handler.Timeout = testDelay / 5 * time.Millisecond
+	status, _ = serveFlow(mux, `{"name": "slow"}`)
+	assert.Equal(t, http.StatusGatewayTimeout, status)
+}
+
+func TestContextFlowFunc(t *testing.T) {
+	input, output := createGreetingFlow()
+	flowFunc := Collar.ToContextFlowFunc(input, output)
+
+	r, err := flowFunc(context.Background(), map[string]interface{}{"name": "collar"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello collar", r["greeting"])
+
+	ctx, cancel := context.WithTimeout(context.Background(), testDelay/5*time.Millisecond)
+	defer cancel()
+	_, err = flowFunc(ctx, map[string]interface{}{"name": "slow"})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, httpStatus(CreateHTTPError(http.StatusNotFound, "not found")))
+	assert.Equal(t, http.StatusTooManyRequests, httpStatus(ErrRateLimited))
+	assert.Equal(t, http.StatusServiceUnavailable, httpStatus(&CircuitOpenError{RetryAt: time.Now()}))
+	assert.Equal(t, http.StatusInternalServerError, httpStatus(assert.AnError))
+
+	recorder := httptest.NewRecorder()
+	writeHTTPError(recorder, &CircuitOpenError{RetryAt: time.Now().Add(3 * time.Second)})
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.NotEqual(t, "", recorder.Header().Get("Retry-After"))
+
+	// the internal errors are not exposed
+	recorder = httptest.NewRecorder()
+	writeHTTPError(recorder, errors.New("db password is wrong"))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "password")
+}
+
+func TestFlowHandlerMaxBodySize(t *testing.T) {
+	input, output := createGreetingFlow()
+	handler := CreateFlowHandler(input, output)
+	handler.MaxBodySize = 16
+
+	status, _ := serveFlow(handler, `{"name": "collar"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+
+	status, _ = serveFlow(handler, `{"name": "c"}`)
+	assert.Equal(t, http.StatusOK, status)
+}
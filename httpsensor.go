@@ -0,0 +1,204 @@
+package collargo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeaderTagPrefix the prefix of the tags holding the http headers, followed by the lower case header name
+const HeaderTagPrefix string = "header."
+
+// headerTags add the http headers to the signal tags, the values of a header are separated by commas
+func headerTags(s Signal, header http.Header) Signal {
+	for name, values := range header {
+		s = s.SetTag(HeaderTagPrefix+strings.ToLower(name), strings.Join(values, ","))
+	}
+	return s
+}
+
+// Webhook the webhook sensor, an http handler sending a signal for each request:
+// the json body is the payload, the headers are tags. It is running once created, until it is stopped
+type Webhook struct {
+	Sensor
+}
+
+func (webhook Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !webhook.Running() {
+		writeHTTPError(w, CreateHTTPError(http.StatusServiceUnavailable, "webhook is stopped"))
+		return
+	}
+
+	data, err := decodeRequestBody(w, r, DefaultMaxBodySize)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	signal := CreateSignal(data).
+		SetTag("http.method", r.Method).
+		SetTag("http.path", r.URL.Path)
+	signal = headerTags(signal, r.Header)
+
+	webhook.Send(signal)
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"id": signal.ID,
+	})
+}
+
+// createWebhook create a webhook sensor
+func createWebhook(ns Namespace, comment string) Webhook {
+	// the requests are sent by ServeHTTP, there is nothing to watch
+	sensor := ns.Sensor(comment, func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+		return nil
+	}, false)
+	sensor.SetType("sensor.webhook")
+
+	return Webhook{
+		Sensor: sensor,
+	}
+}
+
+// DefaultHTTPPollTimeout the time limit of the requests of the http poll sensors without client
+const DefaultHTTPPollTimeout = 30 * time.Second
+
+// defaultPollClient the client of the http poll sensors without client
+var defaultPollClient = &http.Client{
+	Timeout: DefaultHTTPPollTimeout,
+}
+
+// HTTPPollSensor the sensor polling an http url at a regular interval: a signal is sent for each changed
+// response, with the json body (or the text body as anonymous payload) as payload, and an error signal
+// for each failed request
+type HTTPPollSensor struct {
+	Sensor
+	lock     sync.Mutex
+	url      string
+	interval time.Duration
+	etag     string
+	// Client the http client used to poll, a client with the DefaultHTTPPollTimeout time limit if nil
+	Client *http.Client
+}
+
+// createHTTPPollSensor create an http poll sensor, returns an error if the interval is not positive
+func createHTTPPollSensor(ns Namespace, comment string, url string, interval time.Duration) (*HTTPPollSensor, error) {
+	if interval <= 0 {
+		return nil, errors.New("HTTPPoll expects a positive interval, got " + interval.String())
+	}
+
+	poller := &HTTPPollSensor{
+		url:      url,
+		interval: interval,
+	}
+
+	poller.Sensor = ns.Sensor(comment, poller.watch, true)
+	poller.SetType("sensor.http")
+	poller.AddMeta("url", url)
+
+	return poller, nil
+}
+
+// Start start to poll
+func (poller *HTTPPollSensor) Start() *HTTPPollSensor {
+	poller.Watch(SensorOptions{"url": poller.url})
+	return poller
+}
+
+// watch poll the url of the options until the sensor is stopped, the pending request is then canceled
+func (poller *HTTPPollSensor) watch(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+	url, ok := options["url"].(string)
+	if !ok {
+		url = poller.url
+	}
+
+	ticker := time.NewTicker(poller.interval)
+	defer ticker.Stop()
+
+	for {
+		if data, changed := poller.poll(ctx, url); changed {
+			send(data)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll request the url, the response is not sent if it has the etag of the previous one,
+// nor if the request is canceled
+func (poller *HTTPPollSensor) poll(ctx context.Context, url string) (interface{}, bool) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err, true
+	}
+	request = request.WithContext(ctx)
+
+	poller.lock.Lock()
+	etag := poller.etag
+	client := poller.Client
+	poller.lock.Unlock()
+
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+	if client == nil {
+		client = defaultPollClient
+	}
+
+	response, err := client.Do(request)
+	if ctx.Err() != nil {
+		return nil, false
+	}
+	if err != nil {
+		return err, true
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified {
+		return nil, false
+	}
+	if response.StatusCode >= 300 {
+		return CreateHTTPError(response.StatusCode, "Failed to poll "+url+": "+response.Status), true
+	}
+
+	responseETag := response.Header.Get("ETag")
+	if responseETag != "" && responseETag == etag {
+		return nil, false
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err, true
+	}
+
+	poller.lock.Lock()
+	poller.etag = responseETag
+	poller.lock.Unlock()
+
+	var data interface{}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&data); err != nil || data == nil {
+		data = string(body)
+	}
+
+	signal := CreateSignal(data).
+		SetTag("http.status", strconv.Itoa(response.StatusCode))
+	return headerTags(signal, response.Header), true
+}
+
+// ETag get the etag of the last response
+func (poller *HTTPPollSensor) ETag() string {
+	poller.lock.Lock()
+	defer poller.lock.Unlock()
+	return poller.etag
+}
@@ -0,0 +1,151 @@
+package collargo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhook(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	webhook := ns.Webhook("webhook")
+	assert.Equal(t, "sensor.webhook", webhook.Type())
+	sent := collectSignals(webhook)
+
+	server := httptest.NewServer(webhook)
+	defer server.Close()
+
+	request, _ := http.NewRequest(http.MethodPost, server.URL+"/hooks/push", strings.NewReader(`{"ref": "master"}`))
+	request.Header.Set("X-Event", "push")
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, response.StatusCode)
+
+	response, err = http.Post(server.URL, "application/json", strings.NewReader(`{"ref": `))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	signals := sent()
+	assert.Equal(t, 1, len(signals))
+	assert.Equal(t, []interface{}{"master"}, payloadValues(signals, "ref"))
+	event, _ := signals[0].GetTag("header.x-event")
+	assert.Equal(t, "push", event)
+	path, _ := signals[0].GetTag("http.path")
+	assert.Equal(t, "/hooks/push", path)
+}
+
+func TestHTTPPollSensor(t *testing.T) {
+	var mutex sync.Mutex
+	version := 1
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests++
+
+		etag := `"v` + strconv.Itoa(version) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`{"version": ` + strconv.Itoa(version) + `}`))
+	}))
+	defer server.Close()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	poller, err := ns.HTTPPoll("poller", server.URL, 20*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, "sensor.http", poller.Type())
+	sent := collectSignals(poller)
+
+	poller.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	mutex.Lock()
+	version = 2
+	mutex.Unlock()
+	time.Sleep(100 * time.Millisecond)
+
+	poller.Stop()
+	poller.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	mutex.Lock()
+	polled := requests
+	mutex.Unlock()
+	assert.True(t, polled > 4)
+
+	assert.Equal(t, []interface{}{float64(1), float64(2)}, payloadValues(sent(), "version"))
+	assert.Equal(t, `"v2"`, poller.ETag())
+
+	time.Sleep(50 * time.Millisecond)
+	mutex.Lock()
+	assert.Equal(t, polled, requests)
+	mutex.Unlock()
+}
+
+func TestHTTPPollSensorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	poller, err := ns.HTTPPoll("poller", server.URL, time.Hour)
+	assert.Nil(t, err)
+	sent := collectSignals(poller)
+
+	poller.Start()
+	time.Sleep(50 * time.Millisecond)
+	poller.Stop()
+
+	signals := sent()
+	assert.Equal(t, 1, len(signals))
+	assert.Equal(t, http.StatusServiceUnavailable, httpStatus(signals[0].Error))
+}
+
+func TestHTTPPollSensorStopCancels(t *testing.T) {
+	canceled := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			canceled <- true
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	poller, err := ns.HTTPPoll("poller", server.URL, time.Hour)
+	assert.Nil(t, err)
+	sent := collectSignals(poller)
+
+	poller.Start()
+	time.Sleep(20 * time.Millisecond)
+	poller.Stop()
+
+	select {
+	case <-canceled:
+	case <-time.After(500 * time.Millisecond):
+		assert.Fail(t, "the pending request is not canceled")
+	}
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, len(sent()))
+}
+
+func TestHTTPPollSensorInterval(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	_, err := ns.HTTPPoll("poller", "http://localhost", 0)
+	assert.NotNil(t, err)
+	_, err = ns.HTTPPoll("poller", "http://localhost", -time.Second)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,34 @@
+package collargo
+
+import (
+	"fmt"
+)
+
+// KeyFunc the function extracting a key from a signal, used to partition signals
+type KeyFunc func(s Signal) string
+
+// KeyByPayload get a key function using the value of a payload field as key
+func KeyByPayload(name string) KeyFunc {
+	return func(s Signal) string {
+		v, ok := s.Get(name)
+		if !ok {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+// KeyByTag get a key function using the value of a signal tag as key
+func KeyByTag(name string) KeyFunc {
+	return func(s Signal) string {
+		tag, _ := s.GetTag(name)
+		return tag
+	}
+}
+
+// KeyBySignalID get a key function using the signal id as key
+func KeyBySignalID() KeyFunc {
+	return func(s Signal) string {
+		return s.ID
+	}
+}
@@ -0,0 +1,20 @@
+package collargo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestKeyFunc(t *testing.T) {
+	s := CreateSignal(map[string]interface{}{
+		"user": "bob",
+		"age":  42,
+	}).SetTag("region", "eu")
+
+	assert.Equal(t, "bob", KeyByPayload("user")(s))
+	assert.Equal(t, "42", KeyByPayload("age")(s))
+	assert.Equal(t, "", KeyByPayload("missing")(s))
+	assert.Equal(t, "eu", KeyByTag("region")(s))
+	assert.Equal(t, "", KeyByTag("missing")(s))
+	assert.Equal(t, s.ID, KeyBySignalID()(s))
+}
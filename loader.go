@@ -0,0 +1,381 @@
+package collargo
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"reflect"
+	"sync"
+)
+
+// GraphSpec the declarative definition of a graph, in yaml or json
+//
+//	namespaces:
+//	  - name: com.example.orders
+//	    metadata: {module: orders}
+//	    nodes:
+//	      - type: input
+//	        comment: "@orders incoming orders #api"
+//	      - type: when
+//	        comment: "@valid valid orders"
+//	        callback: isValidOrder
+//	edges:
+//	  - from: orders
+//	    to: valid
+//	    comment: "checked orders #checked"
+type GraphSpec struct {
+	Namespaces []NamespaceSpec `yaml:"namespaces"`
+	Edges      []EdgeSpec      `yaml:"edges"`
+}
+
+// NamespaceSpec the definition of a namespace and its nodes
+type NamespaceSpec struct {
+	Name     string            `yaml:"name"`
+	Metadata map[string]string `yaml:"metadata"`
+	Nodes    []NodeSpec        `yaml:"nodes"`
+}
+
+// NodeSpec the definition of a node
+type NodeSpec struct {
+	ID       string                 `yaml:"id"`       // the reference of the node in the edges, the @name of the comment if empty
+	Type     string                 `yaml:"type"`     // the operator creating the node
+	Comment  string                 `yaml:"comment"`  // the node comment, with @name and #tags
+	Callback string                 `yaml:"callback"` // the name of the callback in the registry
+	Config   map[string]interface{} `yaml:"config"`   // the config of a node type registered in Operators, or the sensor options
+	Meta     map[string]string      `yaml:"meta"`
+}
+
+// EdgeSpec the definition of an edge, the comment gives its label and tags
+type EdgeSpec struct {
+	From    string `yaml:"from"`
+	To      string `yaml:"to"`
+	Comment string `yaml:"comment"`
+}
+
+// Graph the nodes of a loaded graph, indexed by their id in the spec
+type Graph struct {
+	Nodes map[string]Node
+}
+
+// Node get a node of the graph by its id in the spec
+func (graph *Graph) Node(id string) (Node, bool) {
+	n, ok := graph.Nodes[id]
+	return n, ok
+}
+
+// CallbackRegistry the named callbacks the loaded nodes are bound to
+type CallbackRegistry struct {
+	sync.RWMutex
+	callbacks map[string]interface{}
+}
+
+// CreateCallbackRegistry create an empty callback registry
+func CreateCallbackRegistry() *CallbackRegistry {
+	return &CallbackRegistry{
+		callbacks: map[string]interface{}{},
+	}
+}
+
+// Register register a callback with a name, it must be convertible to the callback type of the operators using it
+func (registry *CallbackRegistry) Register(name string, callback interface{}) *CallbackRegistry {
+	registry.Lock()
+	registry.callbacks[name] = callback
+	registry.Unlock()
+	return registry
+}
+
+// Get get a callback by name
+func (registry *CallbackRegistry) Get(name string) (interface{}, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	callback, ok := registry.callbacks[name]
+	return callback, ok
+}
+
+// bind convert the named callback to the callback type target points to
+func (registry *CallbackRegistry) bind(name string, target interface{}) error {
+	callback, ok := registry.Get(name)
+	if !ok {
+		return errors.New("callback " + name + " is not registered")
+	}
+
+	value := reflect.ValueOf(callback)
+	targetValue := reflect.ValueOf(target).Elem()
+	if callback == nil || !value.Type().ConvertibleTo(targetValue.Type()) {
+		return errors.New("callback " + name + " is not a " + targetValue.Type().Name())
+	}
+
+	targetValue.Set(value.Convert(targetValue.Type()))
+	return nil
+}
+
+// nodeLoader check a node spec and bind its callbacks, returns the function creating the node
+type nodeLoader func(spec NodeSpec, registry *CallbackRegistry) (nodeBuilder, error)
+
+// nodeBuilder create a node checked by a loader in a namespace
+type nodeBuilder func(ns Namespace) Node
+
+// nodeLoaders the loaders of the operator types, by node type and by operator name
+var nodeLoaders = map[string]nodeLoader{
+	"endpoint.input":  loadInput,
+	"input":           loadInput,
+	"endpoint.output": loadOutput,
+	"output":          loadOutput,
+	"filter":          loadFilter,
+	"when":            loadFilter,
+	"processor":       loadProcessor,
+	"map":             loadProcessor,
+	"actuator":        loadActuator,
+	"do":              loadActuator,
+	"errorhandler":    loadErrors,
+	"errors":          loadErrors,
+	"flatmap":         loadFlatMap,
+	"sensor":          loadSensor,
+}
+
+func loadInput(spec NodeSpec, registry *CallbackRegistry) (nodeBuilder, error) {
+	return func(ns Namespace) Node {
+		return ns.Input(spec.Comment)
+	}, nil
+}
+
+func loadOutput(spec NodeSpec, registry *CallbackRegistry) (nodeBuilder, error) {
+	return func(ns Namespace) Node {
+		return ns.Output(spec.Comment)
+	}, nil
+}
+
+func loadFilter(spec NodeSpec, registry *CallbackRegistry) (nodeBuilder, error) {
+	var filter FilterCallback
+	if err := registry.bind(spec.Callback, &filter); err != nil {
+		return nil, err
+	}
+	return func(ns Namespace) Node {
+		return ns.Filter(spec.Comment, filter)
+	}, nil
+}
+
+func loadProcessor(spec NodeSpec, registry *CallbackRegistry) (nodeBuilder, error) {
+	var process ProcessCallback
+	if err := registry.bind(spec.Callback, &process); err != nil {
+		return nil, err
+	}
+	return func(ns Namespace) Node {
+		return ns.Processor(spec.Comment, process)
+	}, nil
+}
+
+func loadActuator(spec NodeSpec, registry *CallbackRegistry) (nodeBuilder, error) {
+	var act ActCallback
+	if err := registry.bind(spec.Callback, &act); err != nil {
+		return nil, err
+	}
+	return func(ns Namespace) Node {
+		return ns.Actuator(spec.Comment, act)
+	}, nil
+}
+
+func loadErrors(spec NodeSpec, registry *CallbackRegistry) (nodeBuilder, error) {
+	var handler ErrorCallback
+	if err := registry.bind(spec.Callback, &handler); err != nil {
+		return nil, err
+	}
+	return func(ns Namespace) Node {
+		return ns.Errors(spec.Comment, handler)
+	}, nil
+}
+
+func loadFlatMap(spec NodeSpec, registry *CallbackRegistry) (nodeBuilder, error) {
+	var process FlatMapCallback
+	if err := registry.bind(spec.Callback, &process); err != nil {
+		return nil, err
+	}
+	return func(ns Namespace) Node {
+		return ns.FlatMap(spec.Comment, process)
+	}, nil
+}
+
+func loadSensor(spec NodeSpec, registry *CallbackRegistry) (nodeBuilder, error) {
+	var watch SensorCallback
+	if err := registry.bind(spec.Callback, &watch); err != nil {
+		return nil, err
+	}
+	return func(ns Namespace) Node {
+		// the sensor starts watching once the graph is connected
+		return ns.Sensor(spec.Comment, watch, true)
+	}, nil
+}
+
+// loadRegisteredOperator check the config of a node type registered in Operators and create its processor
+func loadRegisteredOperator(spec NodeSpec, registry *CallbackRegistry) (nodeBuilder, error) {
+	config := OperatorConfig{}
+	for k, v := range spec.Config {
+		config[k] = normalizeYAML(v)
+	}
+	if _, ok := config["comment"]; !ok {
+		config["comment"] = spec.Comment
+	}
+
+	processor, err := Operators.processor(spec.Type, config)
+	if err != nil {
+		return nil, err
+	}
+	return func(ns Namespace) Node {
+		node := createOperatorNode(spec.Type, ns.GetNamespace(), config, processor)
+		for k, v := range ns.GetMetadata() {
+			node.AddMeta(k, v)
+		}
+		return node
+	}, nil
+}
+
+// normalizeYAML convert the maps decoded from yaml to map[string]interface{}
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = normalizeYAML(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = normalizeYAML(item)
+		}
+		return converted
+	}
+	return value
+}
+
+// ParseGraphSpec parse a graph spec in yaml or json
+func ParseGraphSpec(data []byte) (GraphSpec, error) {
+	spec := GraphSpec{}
+	err := yaml.Unmarshal(data, &spec)
+	return spec, err
+}
+
+// LoadGraph create the graph defined by a yaml or json spec, binding the callbacks from the registry
+func LoadGraph(data []byte, registry *CallbackRegistry) (*Graph, error) {
+	spec, err := ParseGraphSpec(data)
+	if err != nil {
+		return nil, err
+	}
+	return BuildGraph(spec, registry)
+}
+
+// LoadGraphFile create the graph defined by a yaml or json file
+func LoadGraphFile(path string, registry *CallbackRegistry) (*Graph, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadGraph(data, registry)
+}
+
+// loadedNode a node spec checked by its loader, waiting to be built
+type loadedNode struct {
+	id      string
+	spec    NodeSpec
+	build   nodeBuilder
+	options SensorOptions
+}
+
+// BuildGraph create the nodes and the edges of a graph spec.
+// The whole spec is checked before any node is created, so that an invalid spec leaves nothing behind
+func BuildGraph(spec GraphSpec, registry *CallbackRegistry) (*Graph, error) {
+	if registry == nil {
+		registry = CreateCallbackRegistry()
+	}
+
+	loaded := make([][]loadedNode, len(spec.Namespaces))
+	ids := map[string]bool{}
+
+	for i, nsSpec := range spec.Namespaces {
+		for _, nodeSpec := range nsSpec.Nodes {
+			id := nodeSpec.ID
+			if id == "" {
+				id = parseNameFromComment(nodeSpec.Comment)
+			}
+			if id == "" {
+				return nil, errors.New("node '" + nodeSpec.Comment + "' in namespace " + nsSpec.Name + " has no id nor @name")
+			}
+			if ids[id] {
+				return nil, errors.New("duplicated node id: " + id)
+			}
+			ids[id] = true
+
+			load, ok := nodeLoaders[nodeSpec.Type]
+			if !ok {
+				if _, registered := Operators.Get(nodeSpec.Type); !registered {
+					return nil, errors.New("unknown node type " + nodeSpec.Type + " for node " + id)
+				}
+				load = loadRegisteredOperator
+			}
+
+			build, err := load(nodeSpec, registry)
+			if err != nil {
+				return nil, errors.New("Failed to load node " + id + ": " + err.Error())
+			}
+
+			options := SensorOptions{}
+			for k, v := range nodeSpec.Config {
+				options[k] = normalizeYAML(v)
+			}
+
+			loaded[i] = append(loaded[i], loadedNode{
+				id:      id,
+				spec:    nodeSpec,
+				build:   build,
+				options: options,
+			})
+		}
+	}
+
+	for _, edgeSpec := range spec.Edges {
+		if !ids[edgeSpec.From] {
+			return nil, errors.New("unknown edge source: " + edgeSpec.From)
+		}
+		if !ids[edgeSpec.To] {
+			return nil, errors.New("unknown edge target: " + edgeSpec.To)
+		}
+	}
+
+	graph := &Graph{
+		Nodes: map[string]Node{},
+	}
+	sensors := []loadedNode{}
+
+	for i, nsSpec := range spec.Namespaces {
+		metadata := nsSpec.Metadata
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		ns := Collar.NS(nsSpec.Name, metadata)
+
+		for _, l := range loaded[i] {
+			n := l.build(ns)
+			for k, v := range l.spec.Meta {
+				n.AddMeta(k, v)
+			}
+			graph.Nodes[l.id] = n
+
+			if _, ok := n.(Sensor); ok {
+				sensors = append(sensors, l)
+			}
+		}
+	}
+
+	for _, edgeSpec := range spec.Edges {
+		graph.Nodes[edgeSpec.From].To(edgeSpec.Comment, graph.Nodes[edgeSpec.To])
+	}
+
+	// the sensors start in the order of the spec
+	for _, l := range sensors {
+		sensor := graph.Nodes[l.id].(Sensor)
+		sensor.Watch(l.options)
+	}
+
+	return graph, nil
+}
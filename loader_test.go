@@ -0,0 +1,139 @@
+package collargo
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const testGraphYAML = `
+namespaces:
+  - name: com.collargo.test.loader
+    metadata:
+      module: loader
+    nodes:
+      - type: input
+        comment: "@numbers incoming numbers #test"
+      - type: when
+        comment: "@positive positive numbers"
+        callback: isPositive
+      - type: map
+        id: double
+        comment: double the numbers
+        callback: double
+        meta:
+          owner: test
+edges:
+  - from: numbers
+    to: positive
+  - from: positive
+    to: double
+    comment: "positive only #checked"
+`
+
+func testCallbackRegistry() *CallbackRegistry {
+	return CreateCallbackRegistry().
+		Register("isPositive", func(s Signal) (bool, error) {
+			v, _ := s.Get(AnonPayload)
+			return v.(int) > 0, nil
+		}).
+		Register("double", ProcessCallback(func(s Signal) (Signal, error) {
+			v, _ := s.Get(AnonPayload)
+			return s.New(v.(int) * 2), nil
+		}))
+}
+
+func TestLoadGraph(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	graph, err := LoadGraph([]byte(testGraphYAML), testCallbackRegistry())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(graph.Nodes))
+
+	numbers, ok := graph.Node("numbers")
+	assert.True(t, ok)
+	assert.Equal(t, "endpoint.input", numbers.Type())
+	assert.Equal(t, "com.collargo.test.loader", numbers.Namespace())
+	assert.Equal(t, []string{"test"}, numbers.Tags())
+	module, _ := numbers.GetMeta("module")
+	assert.Equal(t, "loader", module)
+
+	double, _ := graph.Node("double")
+	owner, _ := double.GetMeta("owner")
+	assert.Equal(t, "test", owner)
+
+	positive, _ := graph.Node("positive")
+	edge := positive.Edges()[double.ID()]
+	assert.Equal(t, "positive only", edge.Label)
+	assert.Equal(t, []string{"checked"}, edge.Tags)
+
+	sent := collectSignals(double)
+	numbers.Push(-1)
+	numbers.Push(2)
+	assert.Equal(t, []interface{}{4}, payloadValues(sent(), AnonPayload))
+}
+
+func TestLoadGraphJSON(t *testing.T) {
+	graph, err := LoadGraph([]byte(`{
+		"namespaces": [{
+			"name": "com.collargo.test.loader",
+			"nodes": [
+				{"type": "input", "comment": "@in"},
+				{"type": "output", "comment": "@out"}
+			]
+		}],
+		"edges": [{"from": "in", "to": "out", "comment": "direct"}]
+	}`), nil)
+	assert.Nil(t, err)
+
+	in, _ := graph.Node("in")
+	out, _ := graph.Node("out")
+	assert.Equal(t, "direct", in.Edges()[out.ID()].Label)
+}
+
+func TestLoadGraphErrors(t *testing.T) {
+	registry := testCallbackRegistry().Register("wrong", func(s Signal) error {
+		return errors.New("wrong")
+	})
+
+	load := func(nodes string, edges string) error {
+		_, err := LoadGraph([]byte("namespaces:\n  - name: test\n    nodes:\n"+nodes+edges), registry)
+		return err
+	}
+
+	assert.Nil(t, load("      - {type: input, comment: '@in'}\n", ""))
+	assert.NotNil(t, load("      - {type: input, comment: 'no name'}\n", ""))
+	assert.NotNil(t, load("      - {type: input, comment: '@in'}\n      - {type: output, comment: '@in'}\n", ""))
+	assert.NotNil(t, load("      - {type: unknown, comment: '@in'}\n", ""))
+	assert.NotNil(t, load("      - {type: map, comment: '@in', callback: missing}\n", ""))
+	assert.NotNil(t, load("      - {type: map, comment: '@in', callback: wrong}\n", ""))
+	assert.NotNil(t, load("      - {type: input, comment: '@in'}\n", "edges:\n  - {from: in, to: out}\n"))
+	assert.NotNil(t, load("      - {type: input, comment: '@in'\n", ""))
+}
+
+func TestLoadGraphInvalidSpecStartsNothing(t *testing.T) {
+	started := make(chan bool, 1)
+	registry := CreateCallbackRegistry().Register("watch", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+		started <- true
+		return nil
+	})
+
+	_, err := LoadGraph([]byte(`
+namespaces:
+  - name: com.collargo.test.loader
+    nodes:
+      - {type: sensor, comment: '@sensor', callback: watch}
+      - {type: map, comment: '@double', callback: missing}
+edges:
+  - {from: sensor, to: double}
+`), registry)
+	assert.NotNil(t, err)
+
+	select {
+	case <-started:
+		assert.Fail(t, "the sensor of an invalid spec must not start")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -1,8 +1,9 @@
 package collargo
 
-// import (
-//  "fmt"
-// )
+import (
+	// "fmt"
+	"time"
+)
 
 // Namespace the namespace
 type Namespace interface {
@@ -34,6 +35,62 @@ type Namespace interface {
 	Input(comment string) Input
 	// Create an output endpoint operator
 	Output(comment string) Output
+
+	// Create a throttle operator, sending at most one signal per interval
+	Throttle(comment string, interval time.Duration, policy ExcessPolicy) Throttle
+	// Create a debounce operator, sending the last signal once no signal is received during wait
+	Debounce(comment string, wait time.Duration) Debounce
+	// Create a token bucket rate limit operator, sending rate signals per second with bursts up to burst signals
+	RateLimit(comment string, rate float64, burst int, policy ExcessPolicy) RateLimit
+	// Create a circuit breaker operator, an actuator short-circuiting signals after repeated failures
+	CircuitBreaker(comment string, act ActCallback, options CircuitBreakerOptions) CircuitBreaker
+
+	// Create a dedupe operator, dropping the signals whose key was already seen
+	Dedupe(comment string, key KeyFunc, options DedupeOptions) Dedupe
+	// Alias of Dedupe
+	Distinct(comment string, key KeyFunc, options DedupeOptions) Dedupe
+
+	// Create a stateful processor operator, with the state of the signal key (shared by all signals if key is nil)
+	// kept in store (in memory if nil)
+	Stateful(comment string, key KeyFunc, store StateStore, process StatefulCallback) Stateful
+
+	// Create a flatmap operator, sending each signal returned by the callback
+	FlatMap(comment string, process FlatMapCallback) FlatMap
+	// Create a batch operator, grouping size signals (or less after maxWait if not 0) into one
+	Batch(comment string, size int, maxWait time.Duration) Batch
+
+	// Create a switch operator, routing each signal to the branch of the first matching case
+	Switch(comment string, cases ...Case) Switch
+	// Alias of Switch
+	Route(comment string, cases ...Case) Switch
+
+	// Create a subflow operator, a composite node wrapping the subgraph between input and output
+	Subflow(comment string, input Node, output Node) Subflow
+
+	// Create a webhook sensor, an http handler sending each request as a signal
+	Webhook(comment string) Webhook
+	// Create an http poll sensor, requesting url at each interval once started
+	HTTPPoll(comment string, url string, interval time.Duration) (*HTTPPollSensor, error)
+
+	// Create an interval sensor, sending a tick signal at each interval once started
+	Interval(comment string, interval time.Duration) (*TimerSensor, error)
+	// Create a cron sensor, sending a tick signal at each time of a cron spec once started
+	Cron(comment string, spec string) (*TimerSensor, error)
+
+	// Create a file tail sensor, sending each line of the file once started
+	TailFile(comment string, path string, options FileSensorOptions) *FileTailSensor
+	// Create a directory watch sensor, sending the created and modified files once started
+	WatchDir(comment string, dir string, options FileSensorOptions) *DirWatchSensor
+
+	// Create a channel sensor, sending the values received from ch once started, and an end signal when it is closed
+	FromChan(comment string, ch interface{}) *ChanSensor
+	// Create a channel sink, sending the received signals to ch
+	ToChan(ch chan<- Signal) ChanSink
+	// Create a collector, keeping the received signals
+	Collect() Collector
+
+	// Create a node of an operator registered in Operators, the comment is the "comment" entry of the config
+	Node(nodeType string, config OperatorConfig) (Node, error)
 }
 
 type namespaceType struct {
@@ -54,7 +111,7 @@ func (ns *namespaceType) GetMetadata() map[string]string {
 */
 // Sensor create a sensor operator
 func (ns *namespaceType) Sensor(comment string, watch SensorCallback, deferWatch bool) Sensor {
-	node := CreateNode(comment, ns.GetNamespace(), sensorProcessor{
+	node := CreateNode(comment, ns.GetNamespace(), &sensorProcessor{
 		watch: watch,
 	})
 
@@ -68,7 +125,7 @@ func (ns *namespaceType) Sensor(comment string, watch SensorCallback, deferWatch
 	}
 
 	if !deferWatch {
-		sensor.Watch("initiated")
+		sensor.Watch(SensorOptions{})
 	}
 
 	return sensor
@@ -202,3 +259,248 @@ func (ns *namespaceType) Output(comment string) Output {
 
 	return output
 }
+
+// Throttle create a throttle operator, panics if the interval is not positive
+func (ns *namespaceType) Throttle(comment string, interval time.Duration, policy ExcessPolicy) Throttle {
+	node := CreateNode(comment, ns.GetNamespace(), newRateLimitProcessor(newThrottleBucket(interval), policy))
+
+	for k, v := range ns.GetMetadata() {
+		node.AddMeta(k, v)
+	}
+	node.SetType("throttle")
+
+	throttle := Throttle{
+		Node: node,
+	}
+
+	return throttle
+}
+
+// Debounce create a debounce operator
+func (ns *namespaceType) Debounce(comment string, wait time.Duration) Debounce {
+	node := CreateNode(comment, ns.GetNamespace(), &debounceProcessor{
+		wait: wait,
+	})
+
+	for k, v := range ns.GetMetadata() {
+		node.AddMeta(k, v)
+	}
+	node.SetType("debounce")
+
+	debounce := Debounce{
+		Node: node,
+	}
+
+	return debounce
+}
+
+// RateLimit create a rate limit operator, panics if the rate or the burst is not positive
+func (ns *namespaceType) RateLimit(comment string, rate float64, burst int, policy ExcessPolicy) RateLimit {
+	node := CreateNode(comment, ns.GetNamespace(), newRateLimitProcessor(newTokenBucket(rate, burst), policy))
+
+	for k, v := range ns.GetMetadata() {
+		node.AddMeta(k, v)
+	}
+	node.SetType("ratelimit")
+
+	limiter := RateLimit{
+		Node: node,
+	}
+
+	return limiter
+}
+
+// CircuitBreaker create a circuit breaker operator
+func (ns *namespaceType) CircuitBreaker(comment string, act ActCallback, options CircuitBreakerOptions) CircuitBreaker {
+	processor := newCircuitBreakerProcessor(act, options)
+	node := CreateNode(comment, ns.GetNamespace(), processor)
+	processor.node = concreteNode(node)
+
+	for k, v := range ns.GetMetadata() {
+		node.AddMeta(k, v)
+	}
+	node.SetType("circuitbreaker")
+
+	breaker := CircuitBreaker{
+		Node: node,
+	}
+
+	return breaker
+}
+
+// Dedupe create a dedupe operator, panics if the key function is nil
+func (ns *namespaceType) Dedupe(comment string, key KeyFunc, options DedupeOptions) Dedupe {
+	node := CreateNode(comment, ns.GetNamespace(), newDedupeProcessor(key, options))
+
+	for k, v := range ns.GetMetadata() {
+		node.AddMeta(k, v)
+	}
+	node.SetType("dedupe")
+
+	dedupe := Dedupe{
+		Node: node,
+	}
+
+	return dedupe
+}
+
+// Distinct alias of Dedupe
+func (ns *namespaceType) Distinct(comment string, key KeyFunc, options DedupeOptions) Dedupe {
+	return ns.Dedupe(comment, key, options)
+}
+
+// Stateful create a stateful processor operator
+func (ns *namespaceType) Stateful(comment string, key KeyFunc, store StateStore, process StatefulCallback) Stateful {
+	node := CreateNode(comment, ns.GetNamespace(), newStatefulProcessor(key, store, process))
+
+	for k, v := range ns.GetMetadata() {
+		node.AddMeta(k, v)
+	}
+	node.SetType("stateful")
+
+	stateful := Stateful{
+		Node: node,
+	}
+
+	return stateful
+}
+
+// FlatMap create a flatmap operator
+func (ns *namespaceType) FlatMap(comment string, process FlatMapCallback) FlatMap {
+	node := CreateNode(comment, ns.GetNamespace(), flatMapProcessor{
+		process: process,
+	})
+
+	for k, v := range ns.GetMetadata() {
+		node.AddMeta(k, v)
+	}
+	node.SetType("flatmap")
+
+	flatMap := FlatMap{
+		Node: node,
+	}
+
+	return flatMap
+}
+
+// Batch create a batch operator
+func (ns *namespaceType) Batch(comment string, size int, maxWait time.Duration) Batch {
+	node := CreateNode(comment, ns.GetNamespace(), &batchProcessor{
+		size:    size,
+		maxWait: maxWait,
+		pending: []Signal{},
+	})
+
+	for k, v := range ns.GetMetadata() {
+		node.AddMeta(k, v)
+	}
+	node.SetType("batch")
+
+	batch := Batch{
+		Node: node,
+	}
+
+	return batch
+}
+
+// Switch create a switch operator, panics if a case name is duplicated or is DefaultBranch
+func (ns *namespaceType) Switch(comment string, cases ...Case) Switch {
+	sw := createSwitch(comment, ns.GetNamespace(), cases)
+
+	for k, v := range ns.GetMetadata() {
+		sw.AddMeta(k, v)
+	}
+
+	return sw
+}
+
+// Route alias of Switch
+func (ns *namespaceType) Route(comment string, cases ...Case) Switch {
+	return ns.Switch(comment, cases...)
+}
+
+// Subflow create a subflow operator
+func (ns *namespaceType) Subflow(comment string, input Node, output Node) Subflow {
+	subflow := createSubflow(comment, ns.GetNamespace(), input, output)
+
+	for k, v := range ns.GetMetadata() {
+		subflow.AddMeta(k, v)
+	}
+
+	return subflow
+}
+
+// Webhook create a webhook sensor
+func (ns *namespaceType) Webhook(comment string) Webhook {
+	return createWebhook(ns, comment)
+}
+
+// HTTPPoll create an http poll sensor, returns an error if the interval is not positive
+func (ns *namespaceType) HTTPPoll(comment string, url string, interval time.Duration) (*HTTPPollSensor, error) {
+	return createHTTPPollSensor(ns, comment, url, interval)
+}
+
+// Interval create an interval sensor, returns an error if the interval is not positive
+func (ns *namespaceType) Interval(comment string, interval time.Duration) (*TimerSensor, error) {
+	return createIntervalSensor(ns, comment, interval)
+}
+
+// Cron create a cron sensor, the spec is a standard cron spec, a spec with seconds (6 fields), or a descriptor
+func (ns *namespaceType) Cron(comment string, spec string) (*TimerSensor, error) {
+	schedule, err := parseCronSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	return createTimerSensor(ns, comment, spec, schedule), nil
+}
+
+// TailFile create a file tail sensor
+func (ns *namespaceType) TailFile(comment string, path string, options FileSensorOptions) *FileTailSensor {
+	return createFileTailSensor(ns, comment, path, options)
+}
+
+// WatchDir create a directory watch sensor
+func (ns *namespaceType) WatchDir(comment string, dir string, options FileSensorOptions) *DirWatchSensor {
+	return createDirWatchSensor(ns, comment, dir, options)
+}
+
+// FromChan create a channel sensor
+func (ns *namespaceType) FromChan(comment string, ch interface{}) *ChanSensor {
+	return createChanSensor(ns, comment, ch)
+}
+
+// ToChan create a channel sink
+func (ns *namespaceType) ToChan(ch chan<- Signal) ChanSink {
+	sink := createChanSink(ns.GetNamespace(), ch)
+
+	for k, v := range ns.GetMetadata() {
+		sink.AddMeta(k, v)
+	}
+
+	return sink
+}
+
+// Collect create a collector
+func (ns *namespaceType) Collect() Collector {
+	collector := createCollector(ns.GetNamespace())
+
+	for k, v := range ns.GetMetadata() {
+		collector.AddMeta(k, v)
+	}
+
+	return collector
+}
+
+// Node create a node of a registered operator
+func (ns *namespaceType) Node(nodeType string, config OperatorConfig) (Node, error) {
+	node, err := Operators.Create(nodeType, ns.GetNamespace(), config)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range ns.GetMetadata() {
+		node.AddMeta(k, v)
+	}
+
+	return node, nil
+}
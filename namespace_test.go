@@ -1,6 +1,7 @@
 package collargo
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
@@ -47,9 +48,10 @@ func ExampleNamespace() {
 }
 
 func TestSensor(t *testing.T) {
-	sensor := Collar.Sensor("test sensor", func(options string, send SendDataFunc) {
+	sensor := Collar.Sensor("test sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
 		time.Sleep(1000 * time.Millisecond)
 		send("text message")
+		return nil
 	}, false)
 
 	node1 := CreateNode("Example Node 1", "com.collartechs.test", passThroughSignalProcessor{})
@@ -70,9 +72,10 @@ func TestSensor(t *testing.T) {
 }
 
 func TestSensorWithDeferWatch(t *testing.T) {
-	sensor := Collar.Sensor("test sensor", func(options string, send SendDataFunc) {
+	sensor := Collar.Sensor("test sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
 		time.Sleep(100 * time.Millisecond)
 		send("text message")
+		return nil
 	}, true)
 
 	node1 := CreateNode("Example Node 1", "com.collartechs.test", passThroughSignalProcessor{})
@@ -88,15 +91,16 @@ func TestSensorWithDeferWatch(t *testing.T) {
 
 	sensor.To("node1", node1)
 
-	sensor.Watch("initiated")
+	sensor.Watch(SensorOptions{})
 
 	time.Sleep(testDelay * time.Millisecond)
 }
 
 func TestProcessor(t *testing.T) {
-	sensor := Collar.Sensor("test sensor", func(options string, send SendDataFunc) {
+	sensor := Collar.Sensor("test sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
 		time.Sleep(100 * time.Millisecond)
 		send(10)
+		return nil
 	}, false)
 
 	sensor.Map("x2", func(s Signal) (Signal, error) {
@@ -122,9 +126,10 @@ func TestProcessor(t *testing.T) {
 }
 
 func TestActuator(t *testing.T) {
-	sensor := Collar.Sensor("test sensor", func(options string, send SendDataFunc) {
+	sensor := Collar.Sensor("test sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
 		time.Sleep(100 * time.Millisecond)
 		send(10)
+		return nil
 	}, false)
 
 	sensor.Map("x2", func(s Signal) (Signal, error) {
@@ -157,9 +162,10 @@ func TestActuator(t *testing.T) {
 }
 
 func TestErrors(t *testing.T) {
-	sensor := Collar.Sensor("test sensor", func(options string, send SendDataFunc) {
+	sensor := Collar.Sensor("test sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
 		time.Sleep(100 * time.Millisecond)
 		send(10)
+		return nil
 	}, false)
 
 	sensor.Map("x2", func(s Signal) (Signal, error) {
@@ -178,9 +184,10 @@ func TestErrors(t *testing.T) {
 }
 
 func TestMultipleFlow(t *testing.T) {
-	sensor := Collar.Sensor("test sensor", func(options string, send SendDataFunc) {
+	sensor := Collar.Sensor("test sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
 		time.Sleep(100 * time.Millisecond)
 		send(10)
+		return nil
 	}, false)
 
 	sensor.Map("x2", func(s Signal) (Signal, error) {
@@ -258,11 +265,12 @@ func TestEndpoint(t *testing.T) {
 func TestNodeConnection(t *testing.T) {
 	ns := Collar.NS("com.collargo.test", map[string]string{})
 
-	sensor := ns.Sensor("test sensor", func(options string, send SendDataFunc) {
+	sensor := ns.Sensor("test sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
 		time.Sleep(100 * time.Millisecond)
 		send(11)
 		time.Sleep(100 * time.Millisecond)
 		send(10)
+		return nil
 	}, false)
 
 	errGen := ns.Do("error generator", func(s Signal) (interface{}, error) {
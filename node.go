@@ -1,14 +1,49 @@
 package collargo
 
 import (
+	"errors"
 	"github.com/satori/go.uuid"
+	"reflect"
 	"regexp"
 	"sync"
+	"time"
 )
 
 // Observer function: observe signal processing
+//
+// the observer is invoked with the node, the event and the signal, the events and their extra arguments are:
+//
+//	"onReceive"   the node received the signal
+//	"send"        the node sends the signal, data[0] is the number of downstream edges (int)
+//	"to"          the node is connected, the signal is empty, data[0] is the downstream node, data[1] the *Edge
+//	"unlink"      the node is unlinked, the signal is empty, data[0] is the downstream node, data[1] the *Edge
+//	"breakpoint"  the node paused on the signal, data[0] is the breakpoint name
+//	"circuit"     the circuit breaker changed its state, data[0] and data[1] are the previous and new CircuitState
+//	"done"        the node is done with the signal it received, or dropped it (only while Collar.TrackActivity is on)
+//	"delivered"   the signal sent is delivered to a downstream node, data[0] is the *Edge (only while Collar.TrackActivity is on)
 type Observer func(Node, string, Signal, ...interface{}) error
 
+// BreakpointCondition the predicate deciding if a node should pause on a received signal
+type BreakpointCondition func(s Signal) bool
+
+// OverflowPolicy decides what to do with a signal received by a paused node when its buffer is full
+type OverflowPolicy int
+
+const (
+	// DropNewest drop the received signal
+	DropNewest OverflowPolicy = iota
+	// DropOldest drop the oldest buffered signal to make room for the received one
+	DropOldest
+	// RejectWithError send an error signal (ErrPauseBufferFull) to the downstream nodes instead
+	RejectWithError
+)
+
+// ErrPauseBufferFull the error of a signal rejected by a paused node
+var ErrPauseBufferFull = errors.New("pause buffer is full")
+
+// DefaultPauseBufferSize the default max number of signals buffered by a paused node
+const DefaultPauseBufferSize = 1000
+
 // SignalProcessor the basic execution unit inside of node
 //
 // a processor must provide 2 processing functions to handle data signal and error signal
@@ -21,6 +56,15 @@ type SignalProcessor interface {
 	OnSignal(s Signal, send SendSignalFunc) error
 }
 
+// UpstreamRegistry the upstream registration of a Node implemented outside of this package
+//
+// the upstreams of the nodes created by this package, or of the types embedding them as Node, are recorded by Connect and Unlink,
+// other implementations must implement UpstreamRegistry to get their upstreams recorded, and Detach and Replace working on them
+type UpstreamRegistry interface {
+	AddUpstream(up Node)    // Record an upstream connected to the node
+	RemoveUpstream(up Node) // Forget an upstream unlinked from the node
+}
+
 // Node the node interface
 type Node interface {
 	ID() string        // Get the id of the node
@@ -33,8 +77,9 @@ type Node interface {
 
 	SetType(string) // Set the node type
 
-	Upstreams() map[string]Node   // Get the upstreams
-	Downstreams() map[string]Node // Get the downstreams
+	Upstreams() map[string]Node   // Get a copy of the upstreams, see UpstreamRegistry for the nodes implemented outside of this package
+	Downstreams() map[string]Node // Get a copy of the downstreams
+	Edges() map[string]*Edge      // Get a copy of the edges to the downstreams, indexed by downstream id
 
 	SignalProcessor() SignalProcessor // Get the signal processor
 
@@ -42,17 +87,34 @@ type Node interface {
 	HasTag(tag string) bool // Check if the node has tag or not
 
 	AddMeta(name string, metadata string) Node // Add a metadata to the node
-	GetAllMeta() map[string]string             // Get all metadatas
+	GetAllMeta() map[string]string             // Get a copy of all metadatas
 	GetMeta(name string) (string, bool)        // Get a metadata with name
 
 	Push(data interface{}) Node // Push data to the node
 	Send(data interface{}) Node // Send data to the downstream nodes
 
-	To(comment string, next Node) Node // Connect the current node to a downstream node
+	To(comment string, next Node) Node            // Connect the current node to a downstream node, the comment gives the edge label and tags
+	Connect(next Node, options EdgeOptions) *Edge // Connect the current node to a downstream node with an edge
+	Unlink(next Node) Node                        // Disconnect the current node from a downstream node
+	Detach() Node                                 // Disconnect the node from all its upstreams, detaching the subgraph it leads
 
 	Observe(observer Observer) // Add an observer
 	Observers() []Observer     // Get All observers of this node
 
+	/* Debugging API */
+	Pause()                                              // Pause the node, received signals are buffered
+	Resume()                                             // Resume the node, process all buffered signals
+	Paused() bool                                        // Check if the node is paused
+	Step() bool                                          // Process the next buffered signal, the node keeps paused
+	AddBreakpoint(name string, cond BreakpointCondition) // Pause the node when a received signal matches the condition
+	RemoveBreakpoint(name string)                        // Remove a breakpoint
+	SetPauseBuffer(size int, policy OverflowPolicy)      // Set the size and overflow policy of the pause buffer
+
+	/* Delivery API */
+	Ordered() Node              // Process received signals one by one, in the order they are sent
+	OrderedBy(key KeyFunc) Node // Process received signals in order per key, signals of different keys in parallel
+	IsOrdered() bool            // Check if the node processes signals in order
+
 	/* Flow related API */
 	GetFlowOutputObserver() (Observer, bool)         // get flow output observer
 	SetFlowOutputObserver(Observer)                  // set flow output observer
@@ -69,6 +131,18 @@ type Node interface {
 	Errors(comment string, errHandler ErrorCallback) ErrorNode
 	Input(comment string) Input
 	Output(comment string) Output
+	Throttle(comment string, interval time.Duration, policy ExcessPolicy) Throttle
+	Debounce(comment string, wait time.Duration) Debounce
+	RateLimit(comment string, rate float64, burst int, policy ExcessPolicy) RateLimit
+	CircuitBreaker(comment string, act ActCallback, options CircuitBreakerOptions) CircuitBreaker
+	Dedupe(comment string, key KeyFunc, options DedupeOptions) Dedupe
+	Stateful(comment string, key KeyFunc, store StateStore, process StatefulCallback) Stateful
+	FlatMap(comment string, process FlatMapCallback) FlatMap
+	Batch(comment string, size int, maxWait time.Duration) Batch
+	Switch(comment string, cases ...Case) Switch
+	Subflow(comment string, input Node, output Node) Subflow
+	ToChan(ch chan<- Signal) ChanSink
+	Collect() Collector
 }
 
 // parseNameFromComment   In the node comment you can put a unique (unique in namespace) name with @ sign
@@ -115,6 +189,9 @@ func parseInfoFromComment(comment string) (name string, tags []string, newCommen
 }
 
 // node the node struct
+//
+// the edge sets and the observers are copy-on-write: they are replaced, never modified,
+// so a snapshot taken under the lock can be iterated without holding it
 type node struct {
 	sync.RWMutex
 	id        string
@@ -125,7 +202,7 @@ type node struct {
 	nodeType  string
 
 	upstreams   map[string]Node
-	downstreams map[string]Node
+	downstreams map[string]*Edge
 
 	tags []string
 	meta map[string]string
@@ -133,6 +210,18 @@ type node struct {
 	observers []Observer
 	processor SignalProcessor
 
+	// property used for pause and breakpoints
+	paused         bool
+	pauseBuffer    []Signal
+	pauseBufSize   int
+	overflowPolicy OverflowPolicy
+	breakpoints    []breakpoint
+
+	// property used for ordered delivery
+	ordered     bool
+	orderKey    KeyFunc
+	orderQueues map[string]*orderQueue
+
 	// property used for flow function
 	flowOutputObserver Observer
 	flowFuncs          map[string]FlowFunc
@@ -157,12 +246,19 @@ func CreateNode(
 	n.namespace = namespace
 	n.observers = []Observer{}
 	n.upstreams = map[string]Node{}
-	n.downstreams = map[string]Node{}
+	n.downstreams = map[string]*Edge{}
 	n.processor = processor
 	n.meta = map[string]string{
 		"namespace": namespace,
 	}
 
+	n.pauseBuffer = []Signal{}
+	n.pauseBufSize = DefaultPauseBufferSize
+	n.overflowPolicy = DropNewest
+	n.breakpoints = []breakpoint{}
+
+	n.orderQueues = map[string]*orderQueue{}
+
 	n.flowOutputObserver = nil
 	n.flowFuncs = map[string]FlowFunc{}
 	n.signalCallbacks = map[string]Callback{}
@@ -190,6 +286,8 @@ func (n *node) Name() string {
 
 // Type Get node type
 func (n *node) Type() string {
+	n.RLock()
+	defer n.RUnlock()
 	if n.nodeType == "" {
 		return "node"
 	}
@@ -198,7 +296,9 @@ func (n *node) Type() string {
 
 // SetType set the node type
 func (n *node) SetType(t string) {
+	n.Lock()
 	n.nodeType = t
+	n.Unlock()
 }
 
 // Namespace Get node namespace
@@ -216,14 +316,23 @@ func (n *node) Comment() string {
 	return n.comment
 }
 
-// Upstreams Get the upstreams of this node
+// Upstreams Get a copy of the upstreams of this node
 func (n *node) Upstreams() map[string]Node {
-	return n.upstreams
+	return copyNodeSet(n.upstreamSet())
 }
 
-// Downstreams Get the downstreams of this node
+// Downstreams Get a copy of the downstreams of this node
 func (n *node) Downstreams() map[string]Node {
-	return n.downstreams
+	downstreams := map[string]Node{}
+	for id, edge := range n.downstreamSet() {
+		downstreams[id] = edge.Target
+	}
+	return downstreams
+}
+
+// Edges Get a copy of the edges to the downstreams of this node
+func (n *node) Edges() map[string]*Edge {
+	return copyEdgeSet(n.downstreamSet())
 }
 
 // SignalProcessor get the signal processor of this node
@@ -248,17 +357,27 @@ func (n *node) HasTag(name string) bool {
 
 // AddMeta add the meta data to node
 func (n *node) AddMeta(name string, data string) Node {
+	n.Lock()
 	n.meta[name] = data
+	n.Unlock()
 	return n
 }
 
-// GetAllMeta get all metadatas attatched to this node
+// GetAllMeta get a copy of all metadatas attatched to this node
 func (n *node) GetAllMeta() map[string]string {
-	return n.meta
+	n.RLock()
+	defer n.RUnlock()
+	meta := make(map[string]string, len(n.meta))
+	for k, v := range n.meta {
+		meta[k] = v
+	}
+	return meta
 }
 
 // GetMeta get the metadata according to name
 func (n *node) GetMeta(name string) (string, bool) {
+	n.RLock()
+	defer n.RUnlock()
 	v, ok := n.meta[name]
 	return v, ok
 }
@@ -279,14 +398,28 @@ func (n *node) onReceive(s Signal) Node {
 		panic(err)
 	}
 
+	if n.hold(s) {
+		return n
+	}
+
+	n.dispatch(s)
+
+	return n
+}
+
+// dispatch schedule the processing of a signal
+func (n *node) dispatch(s Signal) {
+	if n.IsOrdered() {
+		n.enqueue(s)
+		return
+	}
+
 	// fmt.Println("onReceive", s.Payload)
 	if s.Error != nil {
 		Collar.GetExecutor().Schedule(n.processor.OnError, n, s)
 	} else {
 		Collar.GetExecutor().Schedule(n.processor.OnSignal, n, s)
 	}
-
-	return n
 }
 
 // Send send a signal to the downstream nodes
@@ -295,52 +428,276 @@ func (n *node) Send(data interface{}) Node {
 
 	// fmt.Println("send signal", s.Payload)
 
-	err := n.invokeSendObservers(s)
+	edges := n.downstreamSet()
+
+	err := n.invokeSendObservers(s, len(edges))
 
 	if err != nil {
 		panic(err)
 	}
 
-	// Each downstream node handles the signal in a goroutine
-	for _, stream := range n.downstreams {
-		go stream.Push(s)
+	// Each downstream node handles the signal in a goroutine (or as the executor dispatches it),
+	// except the ordered ones which only queue it and need to receive it in order
+	tracking := Collar.trackingActivity()
+	for _, edge := range edges {
+		if edge.Target.IsOrdered() {
+			edge.deliver(s)
+			if tracking {
+				n.notify("delivered", s, edge)
+			}
+		} else {
+			edge := edge
+			dispatch(func() {
+				edge.deliver(s)
+				if tracking {
+					n.notify("delivered", s, edge)
+				}
+			})
+		}
 	}
 
 	return n
 }
 
-// To Connect the current node To the next node
+// To Connect the current node To the next node, the comment gives the label and the tags of the edge
 func (n *node) To(comment string, next Node) Node {
-	err := n.invokeToObservers(next)
+	n.Connect(next, parseEdgeOptions(comment))
+
+	return next
+}
+
+// Connect Connect the current node to the next node with an edge, replacing the previous edge if any
+func (n *node) Connect(next Node, options EdgeOptions) *Edge {
+	edge := newEdge(n, next, options)
+
+	err := n.invokeToObservers(edge)
 
 	if err != nil {
 		panic(err)
 	}
 
-	n.Downstreams()[next.ID()] = next
-	next.Upstreams()[n.ID()] = n
+	n.Lock()
+	downstreams := copyEdgeSet(n.downstreams)
+	downstreams[next.ID()] = edge
+	n.downstreams = downstreams
+	n.Unlock()
+
+	if target := concreteNode(next); target != nil {
+		target.addUpstream(n)
+	} else if registry, ok := next.(UpstreamRegistry); ok {
+		registry.AddUpstream(n)
+	}
+
+	return edge
+}
 
-	return next
+// Unlink Disconnect the current node from the next node, returns the current node
+func (n *node) Unlink(next Node) Node {
+	edge, linked := n.downstreamSet()[next.ID()]
+	if !linked {
+		return n
+	}
+
+	err := n.invokeUnlinkObservers(edge)
+
+	if err != nil {
+		panic(err)
+	}
+
+	n.Lock()
+	downstreams := copyEdgeSet(n.downstreams)
+	delete(downstreams, next.ID())
+	n.downstreams = downstreams
+	n.Unlock()
+
+	if target := concreteNode(next); target != nil {
+		target.removeUpstream(n)
+	} else if registry, ok := next.(UpstreamRegistry); ok {
+		registry.RemoveUpstream(n)
+	}
+
+	return n
+}
+
+// Detach Disconnect the current node from all its upstreams, its downstreams are kept
+func (n *node) Detach() Node {
+	for _, up := range n.upstreamSet() {
+		up.Unlink(n)
+	}
+
+	return n
+}
+
+// concreteNode get the *node behind n, unwrapping the operator types embedding a Node,
+// returns nil if n is implemented outside of this package
+func concreteNode(n Node) *node {
+	for n != nil {
+		if concrete, ok := n.(*node); ok {
+			return concrete
+		}
+
+		v := reflect.Indirect(reflect.ValueOf(n))
+		if v.Kind() != reflect.Struct {
+			return nil
+		}
+		field := v.FieldByName("Node")
+		if !field.IsValid() || !field.Type().Implements(reflect.TypeOf((*Node)(nil)).Elem()) {
+			return nil
+		}
+		n, _ = field.Interface().(Node)
+	}
+	return nil
+}
+
+func (n *node) addUpstream(up Node) {
+	n.Lock()
+	upstreams := copyNodeSet(n.upstreams)
+	upstreams[up.ID()] = up
+	n.upstreams = upstreams
+	n.Unlock()
+}
+
+func (n *node) removeUpstream(up Node) {
+	n.Lock()
+	upstreams := copyNodeSet(n.upstreams)
+	delete(upstreams, up.ID())
+	n.upstreams = upstreams
+	n.Unlock()
 }
 
 // Observe observe the node with an observer
 func (n *node) Observe(observer Observer) {
-	n.observers = append(n.observers, observer)
+	n.Lock()
+	observers := make([]Observer, len(n.observers), len(n.observers)+1)
+	copy(observers, n.observers)
+	n.observers = append(observers, observer)
+	n.Unlock()
 }
 
 // Observers get all observers of this node
 func (n *node) Observers() []Observer {
+	n.RLock()
+	defer n.RUnlock()
 	return n.observers
 }
 
+// Pause pause the node, signals received are buffered until the node is resumed or stepped
+func (n *node) Pause() {
+	n.Lock()
+	n.paused = true
+	n.Unlock()
+}
+
+// Resume resume the node and process all buffered signals, before the signals received after them
+func (n *node) Resume() {
+	n.Lock()
+	// the node keeps buffering until the buffer is drained, the new signals can't overtake the buffered ones
+	for len(n.pauseBuffer) > 0 {
+		buffered := n.pauseBuffer
+		n.pauseBuffer = []Signal{}
+		n.Unlock()
+
+		for _, s := range buffered {
+			n.dispatch(s)
+		}
+
+		n.Lock()
+	}
+	n.paused = false
+	n.Unlock()
+}
+
+// Paused check if the node is paused
+func (n *node) Paused() bool {
+	n.RLock()
+	defer n.RUnlock()
+	return n.paused
+}
+
+// Step process the next buffered signal, returns false if no signal is buffered
+func (n *node) Step() bool {
+	n.Lock()
+	if len(n.pauseBuffer) == 0 {
+		n.Unlock()
+		return false
+	}
+	s := n.pauseBuffer[0]
+	n.pauseBuffer = n.pauseBuffer[1:]
+	n.Unlock()
+
+	n.dispatch(s)
+	return true
+}
+
+// AddBreakpoint pause the node when it receives a signal matching the condition,
+// a breakpoint with the same name is replaced
+func (n *node) AddBreakpoint(name string, cond BreakpointCondition) {
+	n.Lock()
+	breakpoints := []breakpoint{}
+	for _, bp := range n.breakpoints {
+		if bp.name != name {
+			breakpoints = append(breakpoints, bp)
+		}
+	}
+	n.breakpoints = append(breakpoints, breakpoint{
+		name: name,
+		cond: cond,
+	})
+	n.Unlock()
+}
+
+// RemoveBreakpoint remove the breakpoint with name
+func (n *node) RemoveBreakpoint(name string) {
+	n.Lock()
+	breakpoints := []breakpoint{}
+	for _, bp := range n.breakpoints {
+		if bp.name != name {
+			breakpoints = append(breakpoints, bp)
+		}
+	}
+	n.breakpoints = breakpoints
+	n.Unlock()
+}
+
+// SetPauseBuffer set the max number of signals buffered while paused, and the policy applied when it is full
+func (n *node) SetPauseBuffer(size int, policy OverflowPolicy) {
+	n.Lock()
+	n.pauseBufSize = size
+	n.overflowPolicy = policy
+	n.Unlock()
+}
+
+// Ordered process the received signals one by one, in the order they are sent by the upstreams
+func (n *node) Ordered() Node {
+	return n.OrderedBy(func(s Signal) string {
+		return ""
+	})
+}
+
+// OrderedBy process the received signals having the same key in order,
+// signals with different keys are processed in parallel
+func (n *node) OrderedBy(key KeyFunc) Node {
+	n.Lock()
+	n.ordered = true
+	n.orderKey = key
+	n.Unlock()
+	return n
+}
+
+// IsOrdered check if the node processes signals in order
+func (n *node) IsOrdered() bool {
+	n.RLock()
+	defer n.RUnlock()
+	return n.ordered
+}
+
 func (n *node) GetFlowOutputObserver() (Observer, bool) {
-	// n.RLock()
+	n.RLock()
+	defer n.RUnlock()
 	if n.flowOutputObserver == nil {
 		return nil, false
 	}
-	observer := n.flowOutputObserver
-	// n.RUnlock()
-	return observer, true
+	return n.flowOutputObserver, true
 }
 
 func (n *node) SetFlowOutputObserver(observer Observer) {
@@ -356,9 +713,9 @@ func (n *node) AddFlowFunc(outID string, flowFunc FlowFunc) {
 }
 
 func (n *node) GetFlowFunc(outID string) (FlowFunc, bool) {
-	// n.RLock()
+	n.RLock()
 	flowFunc, existed := n.flowFuncs[outID]
-	// n.RUnlock()
+	n.RUnlock()
 	return flowFunc, existed
 }
 
@@ -399,7 +756,7 @@ func (n *node) When(comment string, accept FilterCallback) Filter {
 		Node: filterNode,
 	}
 
-	n.To(comment, filter)
+	n.To("", filter)
 
 	return filter
 }
@@ -415,7 +772,7 @@ func (n *node) Map(comment string, process ProcessCallback) Processor {
 		Node: mapNode,
 	}
 
-	n.To(comment, processor)
+	n.To("", processor)
 
 	return processor
 }
@@ -431,7 +788,7 @@ func (n *node) Do(comment string, act ActCallback) Actuator {
 		Node: actNode,
 	}
 
-	n.To(comment, actuator)
+	n.To("", actuator)
 
 	return actuator
 }
@@ -447,7 +804,7 @@ func (n *node) Errors(comment string, errorHandler ErrorCallback) ErrorNode {
 		Node: errNode,
 	}
 
-	n.To(comment, errors)
+	n.To("", errors)
 
 	return errors
 }
@@ -461,7 +818,7 @@ func (n *node) Input(comment string) Input {
 		Node: inputNode,
 	}
 
-	n.To(comment, input)
+	n.To("", input)
 
 	return input
 }
@@ -475,19 +832,327 @@ func (n *node) Output(comment string) Output {
 		Node: outputNode,
 	}
 
-	n.To(comment, output)
+	n.To("", output)
 
 	return output
 }
 
+func (n *node) Throttle(comment string, interval time.Duration, policy ExcessPolicy) Throttle {
+	throttleNode := CreateNode(comment, n.Namespace(), newRateLimitProcessor(newThrottleBucket(interval), policy))
+
+	throttleNode.SetType("throttle")
+
+	throttle := Throttle{
+		Node: throttleNode,
+	}
+
+	n.To("", throttle)
+
+	return throttle
+}
+
+func (n *node) Debounce(comment string, wait time.Duration) Debounce {
+	debounceNode := CreateNode(comment, n.Namespace(), &debounceProcessor{
+		wait: wait,
+	})
+
+	debounceNode.SetType("debounce")
+
+	debounce := Debounce{
+		Node: debounceNode,
+	}
+
+	n.To("", debounce)
+
+	return debounce
+}
+
+func (n *node) RateLimit(comment string, rate float64, burst int, policy ExcessPolicy) RateLimit {
+	limiterNode := CreateNode(comment, n.Namespace(), newRateLimitProcessor(newTokenBucket(rate, burst), policy))
+
+	limiterNode.SetType("ratelimit")
+
+	limiter := RateLimit{
+		Node: limiterNode,
+	}
+
+	n.To("", limiter)
+
+	return limiter
+}
+
+func (n *node) CircuitBreaker(comment string, act ActCallback, options CircuitBreakerOptions) CircuitBreaker {
+	processor := newCircuitBreakerProcessor(act, options)
+	breakerNode := CreateNode(comment, n.Namespace(), processor)
+	processor.node = concreteNode(breakerNode)
+
+	breakerNode.SetType("circuitbreaker")
+
+	breaker := CircuitBreaker{
+		Node: breakerNode,
+	}
+
+	n.To("", breaker)
+
+	return breaker
+}
+
+func (n *node) Dedupe(comment string, key KeyFunc, options DedupeOptions) Dedupe {
+	dedupeNode := CreateNode(comment, n.Namespace(), newDedupeProcessor(key, options))
+
+	dedupeNode.SetType("dedupe")
+
+	dedupe := Dedupe{
+		Node: dedupeNode,
+	}
+
+	n.To("", dedupe)
+
+	return dedupe
+}
+
+func (n *node) Stateful(comment string, key KeyFunc, store StateStore, process StatefulCallback) Stateful {
+	statefulNode := CreateNode(comment, n.Namespace(), newStatefulProcessor(key, store, process))
+
+	statefulNode.SetType("stateful")
+
+	stateful := Stateful{
+		Node: statefulNode,
+	}
+
+	n.To("", stateful)
+
+	return stateful
+}
+
+func (n *node) FlatMap(comment string, process FlatMapCallback) FlatMap {
+	flatMapNode := CreateNode(comment, n.Namespace(), flatMapProcessor{
+		process: process,
+	})
+
+	flatMapNode.SetType("flatmap")
+
+	flatMap := FlatMap{
+		Node: flatMapNode,
+	}
+
+	n.To("", flatMap)
+
+	return flatMap
+}
+
+func (n *node) Batch(comment string, size int, maxWait time.Duration) Batch {
+	batchNode := CreateNode(comment, n.Namespace(), &batchProcessor{
+		size:    size,
+		maxWait: maxWait,
+		pending: []Signal{},
+	})
+
+	batchNode.SetType("batch")
+
+	batch := Batch{
+		Node: batchNode,
+	}
+
+	n.To("", batch)
+
+	return batch
+}
+
+func (n *node) Switch(comment string, cases ...Case) Switch {
+	sw := createSwitch(comment, n.Namespace(), cases)
+
+	n.To("", sw)
+
+	return sw
+}
+
+func (n *node) Subflow(comment string, input Node, output Node) Subflow {
+	subflow := createSubflow(comment, n.Namespace(), input, output)
+
+	n.To("", subflow)
+
+	return subflow
+}
+
+func (n *node) ToChan(ch chan<- Signal) ChanSink {
+	sink := createChanSink(n.Namespace(), ch)
+
+	n.To("", sink)
+
+	return sink
+}
+
+func (n *node) Collect() Collector {
+	collector := createCollector(n.Namespace())
+
+	n.To("", collector)
+
+	return collector
+}
+
 /*
  private
 */
 
+// upstreamSet get the current upstream set, it must not be modified
+func (n *node) upstreamSet() map[string]Node {
+	n.RLock()
+	defer n.RUnlock()
+	return n.upstreams
+}
+
+// downstreamSet get the current edges to the downstreams, it must not be modified
+func (n *node) downstreamSet() map[string]*Edge {
+	n.RLock()
+	defer n.RUnlock()
+	return n.downstreams
+}
+
+// copyNodeSet copy a set of nodes
+func copyNodeSet(nodes map[string]Node) map[string]Node {
+	copied := make(map[string]Node, len(nodes))
+	for id, n := range nodes {
+		copied[id] = n
+	}
+	return copied
+}
+
+// orderQueue the signals of a key waiting to be processed in order
+type orderQueue struct {
+	signals []Signal
+}
+
+// enqueue queue the signal of an ordered node, a goroutine per key (or a task dispatched by the executor)
+// drains the queue and processes the signals without scheduling them
+func (n *node) enqueue(s Signal) {
+	n.RLock()
+	keyFunc := n.orderKey
+	n.RUnlock()
+
+	key := keyFunc(s)
+
+	n.Lock()
+	queue, running := n.orderQueues[key]
+	if !running {
+		queue = &orderQueue{
+			signals: []Signal{},
+		}
+		n.orderQueues[key] = queue
+	}
+	queue.signals = append(queue.signals, s)
+	n.Unlock()
+
+	if !running {
+		dispatch(func() {
+			n.drain(key, queue)
+		})
+	}
+}
+
+// drain process the queued signals of a key until the queue is empty
+func (n *node) drain(key string, queue *orderQueue) {
+	for {
+		n.Lock()
+		if len(queue.signals) == 0 {
+			delete(n.orderQueues, key)
+			n.Unlock()
+			return
+		}
+		s := queue.signals[0]
+		queue.signals = queue.signals[1:]
+		n.Unlock()
+
+		if s.Error != nil {
+			execute(n.processor.OnError, n, s)
+		} else {
+			execute(n.processor.OnSignal, n, s)
+		}
+	}
+}
+
+// breakpoint a named breakpoint condition
+type breakpoint struct {
+	name string
+	cond BreakpointCondition
+}
+
+// hold buffer the signal if the node is paused or a breakpoint is hit, returns true if the signal is held
+func (n *node) hold(s Signal) bool {
+	n.RLock()
+	paused := n.paused
+	breakpoints := n.breakpoints
+	n.RUnlock()
+
+	hit := ""
+	if !paused {
+		for _, bp := range breakpoints {
+			if bp.cond(s) {
+				hit = bp.name
+				break
+			}
+		}
+		if hit == "" {
+			return false
+		}
+	}
+
+	rejected := false
+	dropped := []Signal{}
+	n.Lock()
+	if hit == "" && !n.paused {
+		// resumed since paused was read
+		n.Unlock()
+		return false
+	}
+	if hit != "" {
+		n.paused = true
+	}
+	if len(n.pauseBuffer) < n.pauseBufSize {
+		n.pauseBuffer = append(n.pauseBuffer, s)
+	} else {
+		switch n.overflowPolicy {
+		case DropOldest:
+			if len(n.pauseBuffer) > 0 {
+				dropped = append(dropped, n.pauseBuffer[0])
+				n.pauseBuffer = append(n.pauseBuffer[1:], s)
+			} else {
+				dropped = append(dropped, s)
+			}
+		case RejectWithError:
+			rejected = true
+		default:
+			dropped = append(dropped, s)
+		}
+	}
+	n.Unlock()
+
+	if hit != "" {
+		err := n.invokeBreakpointObservers(s, hit)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if rejected {
+		n.Send(s.SetError(ErrPauseBufferFull))
+		dropped = append(dropped, s)
+	}
+
+	// the dropped signals will not be processed
+	if Collar.trackingActivity() {
+		for _, d := range dropped {
+			n.notify("done", d)
+		}
+	}
+
+	return true
+}
+
 // invoke Global observers
 func (n *node) invokeGlobalObservers(when string, signal Signal, data ...interface{}) error {
 	var err error
-	for _, observer := range Collar.observers {
+	for _, observer := range Collar.Observers() {
 		err = observer(n, when, signal, data...)
 		if err != nil {
 			// fmt.Println("global observers error", err)
@@ -497,6 +1162,21 @@ func (n *node) invokeGlobalObservers(when string, signal Signal, data ...interfa
 	return nil
 }
 
+// notify invoke the observers with an event emitted by the signal processor
+func (n *node) notify(when string, signal Signal, data ...interface{}) {
+	err := n.invokeGlobalObservers(when, signal, data...)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, observer := range n.Observers() {
+		err = observer(n, when, signal, data...)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
 // invoke OnReceive observers
 func (n *node) invokeOnReceiveObservers(signal Signal) error {
 	// fmt.Println("invoke onReceive", signal.Payload)
@@ -505,7 +1185,7 @@ func (n *node) invokeOnReceiveObservers(signal Signal) error {
 		return err
 	}
 
-	for _, observer := range n.observers {
+	for _, observer := range n.Observers() {
 		err = observer(n, "onReceive", signal)
 		if err != nil {
 			return err
@@ -516,14 +1196,46 @@ func (n *node) invokeOnReceiveObservers(signal Signal) error {
 }
 
 // invoke Send observers
-func (n *node) invokeSendObservers(signal Signal) error {
-	err := n.invokeGlobalObservers("send", signal)
+func (n *node) invokeSendObservers(signal Signal, data ...interface{}) error {
+	err := n.invokeGlobalObservers("send", signal, data...)
+	if err != nil {
+		return err
+	}
+
+	for _, observer := range n.Observers() {
+		err = observer(n, "send", signal, data...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invoke Breakpoint observers
+func (n *node) invokeBreakpointObservers(signal Signal, name string) error {
+	err := n.invokeGlobalObservers("breakpoint", signal, name)
+	if err != nil {
+		return err
+	}
+
+	for _, observer := range n.Observers() {
+		err = observer(n, "breakpoint", signal, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invoke Unlink observers, with the downstream node and the removed edge as data
+func (n *node) invokeUnlinkObservers(edge *Edge) error {
+	err := n.invokeGlobalObservers("unlink", Signal{}, edge.Target, edge)
 	if err != nil {
 		return err
 	}
 
-	for _, observer := range n.observers {
-		err = observer(n, "send", signal)
+	for _, observer := range n.Observers() {
+		err = observer(n, "unlink", Signal{}, edge.Target, edge)
 		if err != nil {
 			return err
 		}
@@ -531,15 +1243,15 @@ func (n *node) invokeSendObservers(signal Signal) error {
 	return nil
 }
 
-// invoke To observers
-func (n *node) invokeToObservers(downstream Node) error {
-	err := n.invokeGlobalObservers("to", Signal{}, downstream)
+// invoke To observers, with the downstream node and the new edge as data
+func (n *node) invokeToObservers(edge *Edge) error {
+	err := n.invokeGlobalObservers("to", Signal{}, edge.Target, edge)
 	if err != nil {
 		return err
 	}
 
-	for _, observer := range n.observers {
-		err = observer(n, "to", Signal{}, downstream)
+	for _, observer := range n.Observers() {
+		err = observer(n, "to", Signal{}, edge.Target, edge)
 		if err != nil {
 			return err
 		}
@@ -1,10 +1,14 @@
 package collargo
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 type passThroughSignalProcessor struct {
@@ -103,6 +107,419 @@ func TestChainedNode(t *testing.T) {
 	node1.Push("test message")
 }
 
+func TestPauseAndResume(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	node1 := CreateNode("test node 1", "com.collartechs.test", passThroughSignalProcessor{})
+	node2 := CreateNode("test node 2", "com.collartechs.test", passThroughSignalProcessor{})
+	node1.To("node2", node2)
+
+	received := make(chan Signal, 10)
+	node2.Observe(func(node Node, when string, signal Signal, data ...interface{}) error {
+		if when == "onReceive" {
+			received <- signal
+		}
+		return nil
+	})
+
+	node1.Pause()
+	assert.True(t, node1.Paused())
+
+	node1.Push(1)
+	node1.Push(2)
+	assert.Equal(t, 0, len(received))
+
+	assert.True(t, node1.Step())
+	assert.Equal(t, 1, len(received))
+	assert.True(t, node1.Paused())
+
+	node1.Resume()
+	assert.Equal(t, 2, len(received))
+	assert.False(t, node1.Paused())
+	assert.False(t, node1.Step())
+}
+
+func TestResumeOrdered(t *testing.T) {
+	node := CreateNode("ordered", "com.collartechs.test", passThroughSignalProcessor{}).Ordered()
+	sent := collectSignals(node)
+
+	node.Pause()
+	for i := 0; i < 100; i++ {
+		node.Push(i)
+	}
+
+	// the signals received while resuming are processed after the buffered ones
+	pushed := make(chan bool)
+	go func() {
+		for i := 100; i < 200; i++ {
+			node.Push(i)
+		}
+		close(pushed)
+	}()
+	node.Resume()
+	<-pushed
+
+	values := payloadValues(waitSignals(t, sent, 200), AnonPayload)
+	ordered := true
+	for i, v := range values {
+		ordered = ordered && v == i
+	}
+	assert.True(t, ordered)
+	assert.False(t, node.Paused())
+}
+
+func TestBreakpoint(t *testing.T) {
+	node := CreateNode("test node", "com.collartechs.test", passThroughSignalProcessor{})
+
+	hits := make(chan string, 10)
+	node.Observe(func(node Node, when string, signal Signal, data ...interface{}) error {
+		if when == "breakpoint" {
+			hits <- data[0].(string)
+		}
+		return nil
+	})
+
+	node.AddBreakpoint("big", func(s Signal) bool {
+		v, _ := s.Get(AnonPayload)
+		return v.(int) > 10
+	})
+
+	node.Push(1)
+	assert.False(t, node.Paused())
+
+	node.Push(11)
+	assert.True(t, node.Paused())
+	assert.Equal(t, "big", <-hits)
+
+	node.RemoveBreakpoint("big")
+	node.Resume()
+	node.Push(12)
+	assert.False(t, node.Paused())
+}
+
+func TestPauseBufferOverflow(t *testing.T) {
+	node1 := CreateNode("test node 1", "com.collartechs.test", passThroughSignalProcessor{})
+	node2 := CreateNode("test node 2", "com.collartechs.test", passThroughSignalProcessor{})
+	node1.To("node2", node2)
+
+	received := make(chan Signal, 10)
+	node2.Observe(func(node Node, when string, signal Signal, data ...interface{}) error {
+		if when == "onReceive" {
+			received <- signal
+		}
+		return nil
+	})
+
+	node1.SetPauseBuffer(1, DropOldest)
+	node1.Pause()
+	node1.Push(1)
+	node1.Push(2)
+	node1.Resume()
+
+	s := <-received
+	v, _ := s.Get(AnonPayload)
+	assert.Equal(t, 2, v.(int))
+
+	node1.SetPauseBuffer(1, RejectWithError)
+	node1.Pause()
+	node1.Push(3)
+	node1.Push(4)
+
+	s = <-received
+	assert.Equal(t, ErrPauseBufferFull, s.Error)
+	v, _ = s.Get(AnonPayload)
+	assert.Equal(t, 4, v.(int))
+
+	node1.Resume()
+	s = <-received
+	v, _ = s.Get(AnonPayload)
+	assert.Equal(t, 3, v.(int))
+}
+
+func TestUnlink(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	node1 := CreateNode("test node 1", "com.collartechs.test", passThroughSignalProcessor{})
+	node2 := CreateNode("test node 2", "com.collartechs.test", passThroughSignalProcessor{})
+
+	unlinked := make(chan Node, 1)
+	node1.Observe(func(node Node, when string, signal Signal, data ...interface{}) error {
+		if when == "unlink" {
+			unlinked <- data[0].(Node)
+		}
+		return nil
+	})
+
+	node2.Observe(func(node Node, when string, signal Signal, data ...interface{}) error {
+		if when == "onReceive" {
+			assert.Fail(t, "should not receive signal after unlink")
+		}
+		return nil
+	})
+
+	node1.To("node2", node2)
+	assert.Equal(t, 1, len(node1.Downstreams()))
+	assert.Equal(t, 1, len(node2.Upstreams()))
+
+	assert.Equal(t, node1, node1.Unlink(node2))
+	assert.Equal(t, node2, <-unlinked)
+	assert.Equal(t, 0, len(node1.Downstreams()))
+	assert.Equal(t, 0, len(node2.Upstreams()))
+
+	// unlink a node not linked does nothing
+	node1.Unlink(node2)
+	assert.Equal(t, 0, len(unlinked))
+
+	node1.Push("test message")
+}
+
+func TestDetach(t *testing.T) {
+	node1 := CreateNode("test node 1", "com.collartechs.test", passThroughSignalProcessor{})
+	node2 := CreateNode("test node 2", "com.collartechs.test", passThroughSignalProcessor{})
+	node3 := CreateNode("test node 3", "com.collartechs.test", passThroughSignalProcessor{})
+	node4 := CreateNode("test node 4", "com.collartechs.test", passThroughSignalProcessor{})
+
+	node1.To("node3", node3)
+	node2.To("node3", node3)
+	node3.To("node4", node4)
+
+	node3.Detach()
+
+	assert.Equal(t, 0, len(node1.Downstreams()))
+	assert.Equal(t, 0, len(node2.Downstreams()))
+	assert.Equal(t, 0, len(node3.Upstreams()))
+	assert.Equal(t, 1, len(node3.Downstreams()))
+	assert.Equal(t, 1, len(node4.Upstreams()))
+}
+
+func TestDetachWrappedNode(t *testing.T) {
+	ns := Collar.NS("com.collartechs.test", map[string]string{})
+	input := ns.Input("input")
+	mapper := ns.Map("x2", func(s Signal) (Signal, error) {
+		return s, nil
+	})
+	input.To("x2", mapper)
+
+	assert.Equal(t, 1, len(mapper.Upstreams()))
+
+	mapper.Detach()
+	assert.Equal(t, 0, len(input.Downstreams()))
+	assert.Equal(t, 0, len(mapper.Upstreams()))
+}
+
+// innerNode gives the embedded node a field name other than Node, so customNode is seen as implemented outside of the package
+type innerNode = Node
+
+type customNode struct {
+	innerNode
+	sync.Mutex
+	upstreams map[string]Node
+}
+
+func (c *customNode) AddUpstream(up Node) {
+	c.Lock()
+	defer c.Unlock()
+	c.upstreams[up.ID()] = up
+}
+
+func (c *customNode) RemoveUpstream(up Node) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.upstreams, up.ID())
+}
+
+func (c *customNode) Upstreams() map[string]Node {
+	c.Lock()
+	defer c.Unlock()
+	upstreams := map[string]Node{}
+	for id, up := range c.upstreams {
+		upstreams[id] = up
+	}
+	return upstreams
+}
+
+func (c *customNode) Detach() Node {
+	for _, up := range c.Upstreams() {
+		up.Unlink(c)
+	}
+	return c
+}
+
+func TestDetachCustomNode(t *testing.T) {
+	input := CreateNode("input", "com.collartechs.test", passThroughSignalProcessor{})
+	custom := &customNode{
+		innerNode: CreateNode("custom", "com.collartechs.test", passThroughSignalProcessor{}),
+		upstreams: map[string]Node{},
+	}
+	assert.Nil(t, concreteNode(custom))
+
+	input.To("custom", custom)
+	assert.Equal(t, 1, len(custom.Upstreams()))
+
+	custom.Detach()
+	assert.Equal(t, 0, len(input.Downstreams()))
+	assert.Equal(t, 0, len(custom.Upstreams()))
+}
+
+func TestReplaceCustomNode(t *testing.T) {
+	input := CreateNode("input", "com.collartechs.test", passThroughSignalProcessor{})
+	custom := &customNode{
+		innerNode: CreateNode("custom", "com.collartechs.test", passThroughSignalProcessor{}),
+		upstreams: map[string]Node{},
+	}
+	replacement := CreateNode("replacement", "com.collartechs.test", passThroughSignalProcessor{})
+	input.To("custom", custom)
+
+	Collar.Replace(custom, replacement)
+	assert.Equal(t, 0, len(custom.Upstreams()))
+	assert.Equal(t, 1, len(replacement.Upstreams()))
+	_, linked := input.Downstreams()[replacement.ID()]
+	assert.True(t, linked)
+}
+
+func TestConcreteNode(t *testing.T) {
+	n := CreateNode("test node", "com.collartechs.test", passThroughSignalProcessor{})
+
+	assert.Equal(t, n, concreteNode(n))
+	assert.Equal(t, n, concreteNode(Filter{Node: n}))
+	assert.Equal(t, n, concreteNode(&Sensor{Node: n}))
+	assert.Nil(t, concreteNode(nil))
+}
+
+func TestConcurrentWiringAndSending(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	source := CreateNode("source", "com.collartechs.test", passThroughSignalProcessor{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			next := CreateNode("next", "com.collartechs.test", passThroughSignalProcessor{})
+			source.To("next", next)
+			next.Observe(func(node Node, when string, signal Signal, data ...interface{}) error {
+				return nil
+			})
+			source.Unlink(next)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			source.Send(i)
+			source.AddMeta("key"+strconv.Itoa(i), "value")
+			source.GetAllMeta()
+		}(i)
+		go func() {
+			defer wg.Done()
+			for _, n := range source.Downstreams() {
+				n.Upstreams()
+				n.Observers()
+			}
+			source.Observe(func(node Node, when string, signal Signal, data ...interface{}) error {
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, len(source.Downstreams()))
+}
+
+func TestOrdered(t *testing.T) {
+	sensor := Collar.Sensor("test sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+		for i := 0; i < 100; i++ {
+			send(i)
+		}
+		return nil
+	}, true)
+
+	var mutex sync.Mutex
+	received := []int{}
+	done := make(chan bool)
+
+	node := CreateNode("ordered", "com.collartechs.test", passThroughSignalProcessor{}).Ordered()
+	assert.True(t, node.IsOrdered())
+
+	node.Observe(func(node Node, when string, signal Signal, data ...interface{}) error {
+		if when == "send" {
+			v, _ := signal.Get(AnonPayload)
+			mutex.Lock()
+			received = append(received, v.(int))
+			if len(received) == 100 {
+				close(done)
+			}
+			mutex.Unlock()
+		}
+		return nil
+	})
+
+	sensor.To("ordered", node)
+	sensor.Watch(SensorOptions{})
+
+	select {
+	case <-done:
+	case <-time.After(testDelay * time.Millisecond):
+		assert.Fail(t, "signals not processed")
+		return
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, i, received[i])
+	}
+}
+
+func TestOrderedByKey(t *testing.T) {
+	sensor := Collar.Sensor("test sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+		for i := 0; i < 100; i++ {
+			send(map[string]interface{}{
+				"key":   strconv.Itoa(i % 4),
+				"value": i,
+			})
+		}
+		return nil
+	}, true)
+
+	var mutex sync.Mutex
+	received := map[string][]int{}
+	count := 0
+	done := make(chan bool)
+
+	node := CreateNode("ordered", "com.collartechs.test", passThroughSignalProcessor{}).OrderedBy(KeyByPayload("key"))
+
+	node.Observe(func(node Node, when string, signal Signal, data ...interface{}) error {
+		if when == "send" {
+			key, _ := signal.Get("key")
+			v, _ := signal.Get("value")
+			mutex.Lock()
+			received[key.(string)] = append(received[key.(string)], v.(int))
+			count++
+			if count == 100 {
+				close(done)
+			}
+			mutex.Unlock()
+		}
+		return nil
+	})
+
+	sensor.To("ordered", node)
+	sensor.Watch(SensorOptions{})
+
+	select {
+	case <-done:
+	case <-time.After(testDelay * time.Millisecond):
+		assert.Fail(t, "signals not processed")
+		return
+	}
+
+	assert.Equal(t, 4, len(received))
+	for key, values := range received {
+		k, _ := strconv.Atoi(key)
+		for i, v := range values {
+			assert.Equal(t, k+4*i, v)
+		}
+	}
+}
+
 /* private method tests */
 
 func TestParseNameFromComment(t *testing.T) {
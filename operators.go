@@ -0,0 +1,218 @@
+package collargo
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OperatorConfig the configuration of a node created from a registered operator,
+// the "comment" entry is the node comment
+type OperatorConfig map[string]interface{}
+
+// OperatorFactory create the signal processor of a registered operator from a validated configuration
+type OperatorFactory func(config OperatorConfig) (SignalProcessor, error)
+
+// ConfigField the definition of a configuration entry
+type ConfigField struct {
+	// the expected type: "string", "number", "bool", "duration", "list", "map", or "" for any type
+	Type        string      `json:"type"`
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// OperatorSchema the schema of an operator configuration, by entry name
+type OperatorSchema map[string]ConfigField
+
+// Operator a registered operator
+type Operator struct {
+	Type    string
+	Schema  OperatorSchema
+	Factory OperatorFactory
+}
+
+// OperatorRegistry the registry of the operators, by node type
+type OperatorRegistry struct {
+	sync.RWMutex
+	operators map[string]Operator
+}
+
+// Operators the default operator registry, used by Namespace.Node and the graph loader
+var Operators = CreateOperatorRegistry()
+
+// RegisterOperator register an operator in the default registry
+func RegisterOperator(nodeType string, schema OperatorSchema, factory OperatorFactory) error {
+	return Operators.Register(nodeType, schema, factory)
+}
+
+// CreateOperatorRegistry create an empty operator registry
+func CreateOperatorRegistry() *OperatorRegistry {
+	return &OperatorRegistry{
+		operators: map[string]Operator{},
+	}
+}
+
+// Register register an operator with its node type, a type can only be registered once
+func (registry *OperatorRegistry) Register(nodeType string, schema OperatorSchema, factory OperatorFactory) error {
+	if nodeType == "" {
+		return errors.New("operator type is empty")
+	}
+	if factory == nil {
+		return errors.New("operator " + nodeType + " has no factory")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, existed := registry.operators[nodeType]; existed {
+		return errors.New("operator " + nodeType + " is already registered")
+	}
+
+	if schema == nil {
+		schema = OperatorSchema{}
+	}
+
+	registry.operators[nodeType] = Operator{
+		Type:    nodeType,
+		Schema:  schema,
+		Factory: factory,
+	}
+	return nil
+}
+
+// Get get a registered operator by node type
+func (registry *OperatorRegistry) Get(nodeType string) (Operator, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	operator, ok := registry.operators[nodeType]
+	return operator, ok
+}
+
+// Types get the sorted registered node types
+func (registry *OperatorRegistry) Types() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	types := []string{}
+	for nodeType := range registry.operators {
+		types = append(types, nodeType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Create create a node of a registered type in a namespace, the configuration is validated against the schema
+func (registry *OperatorRegistry) Create(nodeType string, namespace string, config OperatorConfig) (Node, error) {
+	processor, err := registry.processor(nodeType, config)
+	if err != nil {
+		return nil, err
+	}
+	return createOperatorNode(nodeType, namespace, config, processor), nil
+}
+
+// processor validate the configuration of a registered type and create the processor of its node
+func (registry *OperatorRegistry) processor(nodeType string, config OperatorConfig) (SignalProcessor, error) {
+	operator, ok := registry.Get(nodeType)
+	if !ok {
+		return nil, errors.New("operator " + nodeType + " is not registered")
+	}
+
+	validated, err := operator.Schema.Validate(config)
+	if err != nil {
+		return nil, errors.New("invalid " + nodeType + " config: " + err.Error())
+	}
+
+	return operator.Factory(validated)
+}
+
+// createOperatorNode create the node of a registered type with the processor created from its configuration
+func createOperatorNode(nodeType string, namespace string, config OperatorConfig, processor SignalProcessor) Node {
+	comment, _ := config["comment"].(string)
+	node := CreateNode(comment, namespace, processor)
+	node.SetType(nodeType)
+	return node
+}
+
+// Validate check a configuration against the schema, returns the configuration with the defaults applied
+func (schema OperatorSchema) Validate(config OperatorConfig) (OperatorConfig, error) {
+	validated := OperatorConfig{}
+
+	for name, value := range config {
+		if name == "comment" {
+			validated[name] = value
+			continue
+		}
+
+		field, ok := schema[name]
+		if !ok {
+			return nil, errors.New("unknown entry " + name)
+		}
+
+		converted, ok := convertConfigValue(field.Type, value)
+		if !ok {
+			return nil, errors.New("entry " + name + " must be a " + field.Type)
+		}
+		validated[name] = converted
+	}
+
+	for name, field := range schema {
+		if _, ok := validated[name]; ok {
+			continue
+		}
+		if field.Required {
+			return nil, errors.New("missing required entry " + name)
+		}
+		if field.Default != nil {
+			converted, ok := convertConfigValue(field.Type, field.Default)
+			if !ok {
+				return nil, errors.New("default of entry " + name + " must be a " + field.Type)
+			}
+			validated[name] = converted
+		}
+	}
+
+	return validated, nil
+}
+
+// convertConfigValue check the type of a configuration value, numbers are converted to float64,
+// durations to time.Duration
+func convertConfigValue(fieldType string, value interface{}) (interface{}, bool) {
+	switch fieldType {
+	case "string":
+		v, ok := value.(string)
+		return v, ok
+	case "bool":
+		v, ok := value.(bool)
+		return v, ok
+	case "number":
+		switch v := value.(type) {
+		case int:
+			return float64(v), true
+		case int64:
+			return float64(v), true
+		case float32:
+			return float64(v), true
+		case float64:
+			return v, true
+		}
+		return nil, false
+	case "duration":
+		switch v := value.(type) {
+		case time.Duration:
+			return v, true
+		case string:
+			d, err := time.ParseDuration(v)
+			return d, err == nil
+		}
+		return nil, false
+	case "list":
+		v, ok := value.([]interface{})
+		return v, ok
+	case "map":
+		v, ok := value.(map[string]interface{})
+		return v, ok
+	}
+	return value, true
+}
@@ -0,0 +1,139 @@
+package collargo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// multiplyProcessor multiply the anonymous payload by a factor
+type multiplyProcessor struct {
+	factor float64
+}
+
+func (processor multiplyProcessor) OnError(s Signal, send SendSignalFunc) error {
+	send(s)
+	return nil
+}
+
+func (processor multiplyProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	v, _ := s.Get(AnonPayload)
+	send(s.New(v.(float64) * processor.factor))
+	return nil
+}
+
+var multiplySchema = OperatorSchema{
+	"factor": ConfigField{Type: "number", Required: true},
+	"label":  ConfigField{Type: "string", Default: "multiply"},
+}
+
+func multiplyFactory(config OperatorConfig) (SignalProcessor, error) {
+	return multiplyProcessor{factor: config["factor"].(float64)}, nil
+}
+
+func TestOperatorRegistry(t *testing.T) {
+	registry := CreateOperatorRegistry()
+
+	assert.Nil(t, registry.Register("test.multiply", multiplySchema, multiplyFactory))
+	assert.NotNil(t, registry.Register("test.multiply", multiplySchema, multiplyFactory))
+	assert.NotNil(t, registry.Register("", multiplySchema, multiplyFactory))
+	assert.NotNil(t, registry.Register("test.nil", nil, nil))
+	assert.Nil(t, registry.Register("test.any", nil, multiplyFactory))
+	assert.Equal(t, []string{"test.any", "test.multiply"}, registry.Types())
+
+	node, err := registry.Create("test.multiply", "com.collargo.test", OperatorConfig{
+		"comment": "@triple triple it",
+		"factor":  3,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "test.multiply", node.Type())
+	assert.Equal(t, "triple", node.Name())
+	assert.Equal(t, 3.0, node.SignalProcessor().(multiplyProcessor).factor)
+
+	_, err = registry.Create("test.unknown", "com.collargo.test", OperatorConfig{})
+	assert.NotNil(t, err)
+	_, err = registry.Create("test.multiply", "com.collargo.test", OperatorConfig{})
+	assert.NotNil(t, err)
+}
+
+func TestOperatorSchemaValidate(t *testing.T) {
+	schema := OperatorSchema{
+		"url":     ConfigField{Type: "string", Required: true},
+		"timeout": ConfigField{Type: "duration", Default: time.Second},
+		"retry":   ConfigField{Type: "bool"},
+		"headers": ConfigField{Type: "map"},
+		"any":     ConfigField{},
+	}
+
+	config, err := schema.Validate(OperatorConfig{"url": "http://localhost", "timeout": "5s", "any": 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Second, config["timeout"])
+	assert.Equal(t, 1, config["any"])
+	_, hasRetry := config["retry"]
+	assert.False(t, hasRetry)
+
+	config, _ = schema.Validate(OperatorConfig{"url": "http://localhost"})
+	assert.Equal(t, time.Second, config["timeout"])
+
+	_, err = schema.Validate(OperatorConfig{})
+	assert.NotNil(t, err)
+	_, err = schema.Validate(OperatorConfig{"url": 1})
+	assert.NotNil(t, err)
+	_, err = schema.Validate(OperatorConfig{"url": "http://localhost", "timeout": "soon"})
+	assert.NotNil(t, err)
+	_, err = schema.Validate(OperatorConfig{"url": "http://localhost", "headers": []interface{}{}})
+	assert.NotNil(t, err)
+	_, err = schema.Validate(OperatorConfig{"url": "http://localhost", "unknown": true})
+	assert.NotNil(t, err)
+
+	// the defaults are converted like the values
+	config, err = OperatorSchema{
+		"retries": ConfigField{Type: "number", Default: 3},
+		"timeout": ConfigField{Type: "duration", Default: "2s"},
+	}.Validate(OperatorConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, float64(3), config["retries"])
+	assert.Equal(t, 2*time.Second, config["timeout"])
+
+	_, err = OperatorSchema{"retries": ConfigField{Type: "number", Default: "three"}}.Validate(OperatorConfig{})
+	assert.NotNil(t, err)
+}
+
+func TestNamespaceNode(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	// ignore the error when the test runs several times
+	RegisterOperator("test.ns.multiply", multiplySchema, multiplyFactory)
+
+	ns := Collar.NS("com.collargo.test", map[string]string{"module": "test"})
+	node, err := ns.Node("test.ns.multiply", OperatorConfig{"comment": "double it", "factor": 2})
+	assert.Nil(t, err)
+	assert.Equal(t, "test.ns.multiply", node.Type())
+	module, _ := node.GetMeta("module")
+	assert.Equal(t, "test", module)
+	assert.Contains(t, operatorTypes()["types"], map[string]interface{}{
+		"type":   "test.ns.multiply",
+		"schema": multiplySchema,
+	})
+
+	sent := collectSignals(node)
+	node.Push(1.5)
+	assert.Equal(t, []interface{}{3.0}, payloadValues(sent(), AnonPayload))
+
+	graph, err := LoadGraph([]byte(`
+namespaces:
+  - name: com.collargo.test.loader
+    nodes:
+      - type: test.ns.multiply
+        comment: "@ten x10"
+        config:
+          factor: 10
+`), nil)
+	assert.Nil(t, err)
+	ten, _ := graph.Node("ten")
+	assert.Equal(t, "test.ns.multiply", ten.Type())
+	assert.Equal(t, "x10", ten.Comment())
+
+	_, err = ns.Node("test.ns.multiply", OperatorConfig{"factor": "two"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,263 @@
+package collargo
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ExcessPolicy decides what to do with the signals exceeding a rate limit
+type ExcessPolicy int
+
+const (
+	// ExcessDelay delay the signal until the rate limit allows it
+	ExcessDelay ExcessPolicy = iota
+	// ExcessDrop drop the signal
+	ExcessDrop
+	// ExcessError send an error signal (ErrRateLimited) instead of the signal
+	ExcessError
+)
+
+// ErrRateLimited the error of a signal rejected by a rate limiting operator
+var ErrRateLimited = errors.New("rate limited")
+
+// DroppedTag the tag recording the number of signals dropped since the previous signal sent
+const DroppedTag = "__dropped__"
+
+/**
+ * Token bucket
+ */
+
+type tokenBucket struct {
+	sync.Mutex
+	rate    float64 // tokens added per second
+	burst   float64 // max number of tokens
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+// newTokenBucket create a bucket adding rate tokens per second, up to burst tokens,
+// panics if the rate or the burst is not positive as the operators using it are chained
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if !(rate > 0) || math.IsInf(rate, 1) {
+		panic("RateLimit expects a positive rate, got " + strconv.FormatFloat(rate, 'g', -1, 64))
+	}
+	if burst < 1 {
+		panic("RateLimit expects a positive burst, got " + strconv.Itoa(burst))
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// newThrottleBucket create a bucket letting one signal through per interval, panics if the interval is not positive
+func newThrottleBucket(interval time.Duration) *tokenBucket {
+	if interval <= 0 {
+		panic("Throttle expects a positive interval, got " + interval.String())
+	}
+	return newTokenBucket(float64(time.Second)/float64(interval), 1)
+}
+
+// take take a token. If none is available and reserve is true, the token is reserved and
+// the time to wait before using it is returned, otherwise the signal is counted as dropped.
+// When a token is taken, the number of signals dropped before it is returned and reset
+func (bucket *tokenBucket) take(reserve bool) (wait time.Duration, ok bool, dropped int) {
+	bucket.Lock()
+	defer bucket.Unlock()
+
+	now := time.Now()
+	bucket.tokens += now.Sub(bucket.last).Seconds() * bucket.rate
+	if bucket.tokens > bucket.burst {
+		bucket.tokens = bucket.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 && !reserve {
+		bucket.dropped++
+		return 0, false, 0
+	}
+
+	bucket.tokens--
+	if bucket.tokens < 0 {
+		wait = time.Duration(-bucket.tokens / bucket.rate * float64(time.Second))
+	}
+
+	dropped = bucket.dropped
+	bucket.dropped = 0
+	return wait, true, dropped
+}
+
+// setDropped tag the signal with the number of signals dropped before it
+func setDropped(s Signal, dropped int) Signal {
+	if dropped <= 0 {
+		return s
+	}
+	return s.SetTag(DroppedTag, strconv.Itoa(dropped))
+}
+
+/**
+ * Signal Processor for RateLimit and Throttle operators
+ */
+
+// delayedSignal a signal waiting for its token
+type delayedSignal struct {
+	s    Signal
+	send SendSignalFunc
+	at   time.Time
+}
+
+type rateLimitProcessor struct {
+	sync.Mutex
+	bucket *tokenBucket
+	policy ExcessPolicy
+
+	// the signals delayed by the ExcessDelay policy, sent in order by a single goroutine
+	delayed  []delayedSignal
+	draining bool
+}
+
+func newRateLimitProcessor(bucket *tokenBucket, policy ExcessPolicy) *rateLimitProcessor {
+	return &rateLimitProcessor{
+		bucket:  bucket,
+		policy:  policy,
+		delayed: []delayedSignal{},
+	}
+}
+
+func (limiter *rateLimitProcessor) OnError(s Signal, send SendSignalFunc) error {
+	send(s)
+	return nil
+}
+
+func (limiter *rateLimitProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	wait, ok, dropped := limiter.bucket.take(limiter.policy == ExcessDelay)
+
+	if !ok {
+		if limiter.policy == ExcessError {
+			return ErrRateLimited
+		}
+		return nil
+	}
+
+	if wait <= 0 {
+		send(setDropped(s, dropped))
+		return nil
+	}
+
+	limiter.Lock()
+	limiter.delayed = append(limiter.delayed, delayedSignal{
+		s:    setDropped(s, dropped),
+		send: send,
+		at:   time.Now().Add(wait),
+	})
+	if !limiter.draining {
+		limiter.draining = true
+		go limiter.drain()
+	}
+	limiter.Unlock()
+
+	return nil
+}
+
+// drain send the delayed signals when their tokens are available, until no signal is delayed
+func (limiter *rateLimitProcessor) drain() {
+	for {
+		limiter.Lock()
+		if len(limiter.delayed) == 0 {
+			limiter.draining = false
+			limiter.Unlock()
+			return
+		}
+		next := limiter.delayed[0]
+		limiter.delayed = limiter.delayed[1:]
+		limiter.Unlock()
+
+		if wait := time.Until(next.at); wait > 0 {
+			time.Sleep(wait)
+		}
+		next.send(next.s)
+	}
+}
+
+/**
+ * Signal Processor for Debounce operator
+ */
+
+type debounceProcessor struct {
+	sync.Mutex
+	wait    time.Duration
+	timer   *time.Timer
+	seq     int
+	pending bool
+	dropped int
+
+	// the latest signal received, sent when the timer of seq fires
+	latest Signal
+	send   SendSignalFunc
+}
+
+func (debounce *debounceProcessor) OnError(s Signal, send SendSignalFunc) error {
+	send(s)
+	return nil
+}
+
+func (debounce *debounceProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	debounce.Lock()
+	defer debounce.Unlock()
+
+	if debounce.pending {
+		debounce.dropped++
+		debounce.timer.Stop()
+	}
+	debounce.pending = true
+	debounce.seq++
+	debounce.latest = s
+	debounce.send = send
+
+	seq := debounce.seq
+	debounce.timer = time.AfterFunc(debounce.wait, func() {
+		debounce.fire(seq)
+	})
+
+	return nil
+}
+
+// fire send the latest signal, unless a newer signal restarted the timer after the timer of seq fired
+func (debounce *debounceProcessor) fire(seq int) {
+	debounce.Lock()
+	if !debounce.pending || seq != debounce.seq {
+		debounce.Unlock()
+		return
+	}
+	s := debounce.latest
+	send := debounce.send
+	dropped := debounce.dropped
+	debounce.pending = false
+	debounce.dropped = 0
+	debounce.latest = Signal{}
+	debounce.send = nil
+	debounce.Unlock()
+
+	send(setDropped(s, dropped))
+}
+
+// Throttle the throttle operator type
+type Throttle struct {
+	Node
+}
+
+// Debounce the debounce operator type
+type Debounce struct {
+	Node
+}
+
+// RateLimit the rate limit operator type
+type RateLimit struct {
+	Node
+}
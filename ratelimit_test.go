@@ -0,0 +1,163 @@
+package collargo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collectSignals collect the signals sent by a node
+func collectSignals(node Node) func() []Signal {
+	var mutex sync.Mutex
+	signals := []Signal{}
+	node.Observe(func(node Node, when string, s Signal, data ...interface{}) error {
+		if when == "send" {
+			mutex.Lock()
+			signals = append(signals, s)
+			mutex.Unlock()
+		}
+		return nil
+	})
+	return func() []Signal {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]Signal{}, signals...)
+	}
+}
+
+// waitSignals wait until the node sent n signals, returns the signals sent
+func waitSignals(t *testing.T, sent func() []Signal, n int) []Signal {
+	deadline := time.Now().Add(time.Second)
+	for len(sent()) < n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	signals := sent()
+	assert.Equal(t, n, len(signals))
+	return signals
+}
+
+func TestRateLimitDrop(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+	limiter := input.RateLimit("limit", 10, 2, ExcessDrop)
+	assert.Equal(t, "ratelimit", limiter.Type())
+
+	sent := collectSignals(limiter)
+
+	for i := 0; i < 5; i++ {
+		input.Push(i)
+	}
+	assert.Equal(t, 2, len(sent()))
+
+	time.Sleep(110 * time.Millisecond)
+	input.Push(5)
+
+	signals := sent()
+	assert.Equal(t, 3, len(signals))
+	v, _ := signals[2].Get(AnonPayload)
+	assert.Equal(t, 5, v)
+	dropped, _ := signals[2].GetTag(DroppedTag)
+	assert.Equal(t, "3", dropped)
+}
+
+func TestRateLimitError(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+	limiter := input.RateLimit("limit", 1, 1, ExcessError)
+
+	sent := collectSignals(limiter)
+
+	input.Push(1)
+	input.Push(2)
+
+	signals := sent()
+	assert.Equal(t, 2, len(signals))
+	assert.Nil(t, signals[0].Error)
+	assert.Equal(t, ErrRateLimited, signals[1].Error)
+}
+
+func TestThrottleDelay(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	throttle := ns.Throttle("throttle", 50*time.Millisecond, ExcessDelay)
+	assert.Equal(t, "throttle", throttle.Type())
+
+	sent := collectSignals(throttle)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		throttle.Push(i)
+	}
+	// the delayed signals don't block the processing
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+	assert.Equal(t, 1, len(sent()))
+
+	signals := waitSignals(t, sent, 3)
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	assert.Equal(t, []interface{}{0, 1, 2}, payloadValues(signals, AnonPayload))
+}
+
+func TestRateLimitNotPositive(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+
+	assert.Panics(t, func() { ns.Throttle("throttle", 0, ExcessDelay) })
+	assert.Panics(t, func() { input.Throttle("throttle", -time.Second, ExcessDrop) })
+	assert.Panics(t, func() { ns.RateLimit("limit", 0, 1, ExcessDelay) })
+	assert.Panics(t, func() { input.RateLimit("limit", -1, 1, ExcessDelay) })
+	assert.Panics(t, func() { ns.RateLimit("limit", math.NaN(), 1, ExcessDelay) })
+	assert.Panics(t, func() { ns.RateLimit("limit", 1, 0, ExcessDrop) })
+	assert.Equal(t, 0, len(input.Downstreams()))
+}
+
+func TestDebounce(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+	debounce := input.Debounce("debounce", 50*time.Millisecond)
+	assert.Equal(t, "debounce", debounce.Type())
+
+	sent := collectSignals(debounce)
+
+	for i := 0; i < 3; i++ {
+		input.Push(i)
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	signals := sent()
+	assert.Equal(t, 1, len(signals))
+	v, _ := signals[0].Get(AnonPayload)
+	assert.Equal(t, 2, v)
+	dropped, _ := signals[0].GetTag(DroppedTag)
+	assert.Equal(t, "2", dropped)
+}
+
+func TestDebounceStaleTimer(t *testing.T) {
+	debounce := &debounceProcessor{wait: time.Hour}
+
+	sent := []Signal{}
+	send := func(s Signal) {
+		sent = append(sent, s)
+	}
+
+	debounce.OnSignal(CreateSignal(1), send)
+	debounce.OnSignal(CreateSignal(2), send)
+
+	// the timer of the first signal fired before it was stopped
+	debounce.fire(1)
+	assert.Equal(t, 0, len(sent))
+
+	debounce.fire(2)
+	assert.Equal(t, 1, len(sent))
+	v, _ := sent[0].Get(AnonPayload)
+	assert.Equal(t, 2, v)
+	dropped, _ := sent[0].GetTag(DroppedTag)
+	assert.Equal(t, "1", dropped)
+}
@@ -1,30 +1,103 @@
 package collargo
 
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
 /**
  * Sensor operator callback
  */
 
-// SensorCallback the callback function for sensor operator
-type SensorCallback func(options string, send SendDataFunc)
+// SensorOptions the structured options of a sensor watch
+type SensorOptions map[string]interface{}
+
+// Decode decode the options into v (a struct pointer for instance), through their json encoding
+func (options SensorOptions) Decode(v interface{}) error {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// SensorCallback the callback function for sensor operator, it watches the external world with the options
+// and sends data with send until it returns or ctx is done (the sensor is stopped or restarted),
+// the returned error is the last error of the sensor
+type SensorCallback func(ctx context.Context, options SensorOptions, send SendDataFunc) error
 
 /**
  * Signal Processor for sensor
  */
 
 type sensorProcessor struct {
-	watch SensorCallback
+	sync.Mutex
+	watch      SensorCallback
+	options    SensorOptions
+	generation int                // incremented on restart and stop, the watches of the previous generations are stale
+	cancel     context.CancelFunc // cancel the context of the watches of the current generation
+	ctx        context.Context
+	running    bool // started and not stopped
+	lastError  error
 }
 
-func (processor sensorProcessor) OnError(s Signal, send SendSignalFunc) error {
+func (processor *sensorProcessor) OnError(s Signal, send SendSignalFunc) error {
 	// send(s)
 	return nil
 }
 
-func (processor sensorProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+func (processor *sensorProcessor) OnSignal(s Signal, send SendSignalFunc) error {
 	// sensor will not process incoming signal
 	return nil
 }
 
+// start record a new watch, returns its context and its generation. A restart cancels the previous watches
+func (processor *sensorProcessor) start(options SensorOptions, restart bool) (context.Context, int) {
+	processor.Lock()
+	defer processor.Unlock()
+
+	if restart || !processor.running {
+		if processor.cancel != nil {
+			processor.cancel()
+		}
+		processor.generation++
+		processor.ctx, processor.cancel = context.WithCancel(context.Background())
+	}
+	processor.options = options
+	processor.running = true
+	return processor.ctx, processor.generation
+}
+
+// stop cancel the watches, the data they send are dropped
+func (processor *sensorProcessor) stop() {
+	processor.Lock()
+	defer processor.Unlock()
+
+	if processor.cancel != nil {
+		processor.cancel()
+		processor.cancel = nil
+	}
+	processor.running = false
+}
+
+// done record the end of a watch and its error
+func (processor *sensorProcessor) done(generation int, err error) {
+	processor.Lock()
+	defer processor.Unlock()
+
+	if generation == processor.generation && err != nil {
+		processor.lastError = err
+	}
+}
+
+// current check if the watch of a generation is neither stale nor stopped
+func (processor *sensorProcessor) current(generation int) bool {
+	processor.Lock()
+	defer processor.Unlock()
+	return generation == processor.generation && processor.running
+}
+
 /**
  * Sensor Node
  */
@@ -34,9 +107,58 @@ type Sensor struct {
 	Node
 }
 
-// Watch start to watch the external world
-func (sensor *Sensor) Watch(options string) {
-	go sensor.SignalProcessor().(sensorProcessor).watch(options, func(data interface{}) {
-		sensor.Send(data)
-	})
+// Watch start to watch the external world with the options
+func (sensor *Sensor) Watch(options SensorOptions) {
+	sensor.watch(options, false)
+}
+
+// Restart start to watch the external world with new options, the previous watches are canceled
+// and the data they send are dropped
+func (sensor *Sensor) Restart(options SensorOptions) {
+	sensor.watch(options, true)
+}
+
+// Stop stop to watch the external world, the watches are canceled and the data they send are dropped
+func (sensor *Sensor) Stop() {
+	processor := sensor.SignalProcessor().(*sensorProcessor)
+	processor.stop()
+}
+
+func (sensor *Sensor) watch(options SensorOptions, restart bool) {
+	processor := sensor.SignalProcessor().(*sensorProcessor)
+	ctx, generation := processor.start(options, restart)
+
+	go func() {
+		err := processor.watch(ctx, options, func(data interface{}) {
+			if processor.current(generation) {
+				sensor.Send(data)
+			}
+		})
+		processor.done(generation, err)
+	}()
+}
+
+// Running check if the sensor is started and not stopped. A sensor whose callback returned is still running:
+// the push style sensors (e.g. webhooks) send their data outside of the callback
+func (sensor *Sensor) Running() bool {
+	processor := sensor.SignalProcessor().(*sensorProcessor)
+	processor.Lock()
+	defer processor.Unlock()
+	return processor.running
+}
+
+// LastError get the last error returned by a watch, nil if none
+func (sensor *Sensor) LastError() error {
+	processor := sensor.SignalProcessor().(*sensorProcessor)
+	processor.Lock()
+	defer processor.Unlock()
+	return processor.lastError
+}
+
+// Options get the options of the last watch
+func (sensor *Sensor) Options() SensorOptions {
+	processor := sensor.SignalProcessor().(*sensorProcessor)
+	processor.Lock()
+	defer processor.Unlock()
+	return processor.options
 }
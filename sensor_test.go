@@ -1,8 +1,10 @@
 package collargo
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -12,7 +14,8 @@ func TestSensorInputSignal(t *testing.T) {
 
 	input := ns.Input("input")
 
-	sensor := ns.Sensor("sensor", func(options string, send SendDataFunc) {
+	sensor := ns.Sensor("sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+		return nil
 	}, false)
 
 	sensor.Map("x 2", func(s Signal) (Signal, error) {
@@ -41,7 +44,8 @@ func TestSensorInputError(t *testing.T) {
 		return nil, errors.New("error")
 	})
 
-	sensor := ns.Sensor("sensor", func(options string, send SendDataFunc) {
+	sensor := ns.Sensor("sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+		return nil
 	}, false)
 
 	input.To("error generator", errGen).To(
@@ -62,3 +66,116 @@ func TestSensorInputError(t *testing.T) {
 
 	time.Sleep(testDelay * time.Millisecond)
 }
+
+func TestSensorOptionsDecode(t *testing.T) {
+	options := SensorOptions{"url": "http://localhost", "retries": 3}
+
+	var config struct {
+		URL     string `json:"url"`
+		Retries int    `json:"retries"`
+	}
+	assert.Nil(t, options.Decode(&config))
+	assert.Equal(t, "http://localhost", config.URL)
+	assert.Equal(t, 3, config.Retries)
+
+	assert.NotNil(t, SensorOptions{"retries": "three"}.Decode(&config))
+}
+
+func TestSensorRestart(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	stopped := make(chan string, 2)
+	sensor := ns.Sensor("sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+		name := options["name"].(string)
+		for {
+			select {
+			case <-ctx.Done():
+				stopped <- name
+				return errors.New("stopped " + name)
+			case <-time.After(10 * time.Millisecond):
+				send(name)
+			}
+		}
+	}, true)
+	sent := collectSignals(sensor)
+
+	assert.False(t, sensor.Running())
+	sensor.Watch(SensorOptions{"name": "first"})
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, sensor.Running())
+
+	// the first watch is canceled on restart
+	sensor.Restart(SensorOptions{"name": "second"})
+	assert.Equal(t, "second", sensor.Options()["name"])
+	assert.Equal(t, "first", <-stopped)
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, sensor.Running())
+
+	sensor.Stop()
+	assert.Equal(t, "second", <-stopped)
+	time.Sleep(10 * time.Millisecond)
+
+	assert.False(t, sensor.Running())
+	assert.Equal(t, "stopped second", sensor.LastError().Error())
+
+	names := payloadValues(sent(), AnonPayload)
+	restarted := false
+	for _, name := range names {
+		if name == "second" {
+			restarted = true
+			continue
+		}
+		// the first watch is muted once restarted
+		assert.False(t, restarted)
+	}
+	assert.True(t, restarted)
+}
+
+func TestSensorRunning(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	// a sensor whose callback returned is running until it is stopped
+	sensor := ns.Sensor("sensor", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+		return nil
+	}, false)
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, sensor.Running())
+
+	sensor.Stop()
+	assert.False(t, sensor.Running())
+
+	webhook := ns.Webhook("webhook")
+	assert.True(t, webhook.Running())
+	webhook.Stop()
+	status, _ := serveFlow(webhook, `{}`)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+}
+
+func TestLoadSensorOptions(t *testing.T) {
+	received := make(chan SensorOptions, 1)
+	registry := CreateCallbackRegistry().Register("watch", func(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+		received <- options
+		return nil
+	})
+
+	_, err := LoadGraph([]byte(`
+namespaces:
+  - name: com.collargo.test.loader
+    nodes:
+      - type: sensor
+        comment: "@source"
+        callback: watch
+        config:
+          topic: orders
+          brokers: [a, b]
+`), registry)
+	assert.Nil(t, err)
+
+	select {
+	case options := <-received:
+		assert.Equal(t, "orders", options["topic"])
+		assert.Equal(t, []interface{}{"a", "b"}, options["brokers"])
+	case <-time.After(testDelay * time.Millisecond):
+		assert.Fail(t, "the sensor is not started")
+	}
+}
@@ -10,6 +10,14 @@ import (
 // AnonPayload the payload name for anonymous payload
 const AnonPayload string = "__anon__"
 
+// ParentTag the tag holding the id of the signal a child signal derives from,
+// the ids are separated by commas for a signal grouping several signals (see Batch)
+const ParentTag string = "__parent__"
+
+// OriginTag the tag holding the id of the signal a child signal derives from through nested children (e.g. FlatMap
+// after FlatMap), the ids are separated by commas for a signal grouping several signals (see Batch)
+const OriginTag string = "__origin__"
+
 // Signal The signal structure, represents a Signal.
 // Signal is an envelope to deliver data through collar graphs
 type Signal struct {
@@ -147,6 +155,41 @@ func (s Signal) New(data interface{}) Signal {
 	return newSignal
 }
 
+// Child Create a new signal derived from current signal, with a new id, the tags of current signal,
+// the id of current signal as ParentTag and the origin of current signal (or its id) as OriginTag.
+// With nil data the payload is copied
+func (s Signal) Child(data interface{}) Signal {
+	var child Signal
+	if signal, ok := data.(Signal); ok {
+		// keep the tags and the error of the signal, adding the missing tags
+		child = signal.Clone()
+		child.Error = signal.Error
+		for k, v := range s.Tags {
+			if _, existed := child.Tags[k]; !existed {
+				child.Tags[k] = v
+			}
+		}
+	} else {
+		child = s.New(data)
+	}
+
+	id := uuid.NewV1().String()
+	child.ID = id
+	child.Seq = id
+	child.Tags[ParentTag] = s.ID
+	child.Tags[OriginTag] = s.origin()
+
+	return child
+}
+
+// origin get the ids of the signals current signal derives from, its own id if it is not a child
+func (s Signal) origin() string {
+	if origin, ok := s.GetTag(OriginTag); ok {
+		return origin
+	}
+	return s.ID
+}
+
 // Get get the  value in the payload with a key
 // return the corresponding payload and true for status, otherwise nil, false
 func (s Signal) Get(name string) (v interface{}, existed bool) {
@@ -204,6 +247,19 @@ func (s Signal) SetError(err error) Signal {
 	return newSignal
 }
 
+// SetEnd mark or unmark the signal as an end signal, returns a new signal keeping the error and the payload
+func (s Signal) SetEnd(end bool) Signal {
+	newSignal := Signal{
+		ID:      s.ID,
+		Seq:     s.Seq,
+		Error:   s.Error,
+		End:     end,
+		Payload: s.Payload,
+		Tags:    s.Tags,
+	}
+	return newSignal
+}
+
 // GetTag  get a tag with tag name
 // return the tag value, and ok status true, otherwise "" and false
 func (s Signal) GetTag(name string) (tag string, ok bool) {
@@ -326,3 +326,53 @@ func TestCreateTypedSignalFromJSON(t *testing.T) {
   CreateSignalFromJSON(jsonStr, &newPayload)
 }
 */
+
+func TestChild(t *testing.T) {
+	s := CreateSignal(map[string]interface{}{"a": 1}).SetTag("tag1", "v1")
+
+	child := s.Child(2)
+	assert.NotEqual(t, s.ID, child.ID)
+	assert.Equal(t, child.ID, child.Seq)
+	parent, _ := child.GetTag(ParentTag)
+	assert.Equal(t, s.ID, parent)
+	tag, _ := child.GetTag("tag1")
+	assert.Equal(t, "v1", tag)
+	v, _ := child.Get(AnonPayload)
+	assert.Equal(t, 2, v)
+
+	// nil data copies the payload
+	child = s.Child(nil)
+	v, _ = child.Get("a")
+	assert.Equal(t, 1, v)
+
+	// the tags of a signal are kept
+	child = s.Child(CreateSignal(3).SetTag("tag1", "v2").SetTag("tag2", "v3"))
+	tag, _ = child.GetTag("tag1")
+	assert.Equal(t, "v2", tag)
+	tag, _ = child.GetTag("tag2")
+	assert.Equal(t, "v3", tag)
+	parent, _ = child.GetTag(ParentTag)
+	assert.Equal(t, s.ID, parent)
+	_, existed := s.GetTag(ParentTag)
+	assert.False(t, existed)
+
+	// the origin is kept through the nested children
+	origin, _ := child.GetTag(OriginTag)
+	assert.Equal(t, s.ID, origin)
+	grandchild := child.Child(4)
+	parent, _ = grandchild.GetTag(ParentTag)
+	assert.Equal(t, child.ID, parent)
+	origin, _ = grandchild.GetTag(OriginTag)
+	assert.Equal(t, s.ID, origin)
+}
+
+func TestSetEnd(t *testing.T) {
+	s := CreateSignal(1).SetError(errors.New("error"))
+
+	end := s.SetEnd(true)
+	assert.True(t, end.End)
+	assert.Equal(t, s.ID, end.ID)
+	assert.Equal(t, "error", end.Error.Error())
+	assert.False(t, s.End)
+	assert.False(t, end.SetEnd(false).End)
+}
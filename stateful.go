@@ -0,0 +1,185 @@
+package collargo
+
+import (
+	"sync"
+)
+
+/**
+ * Stateful Operator callback
+ */
+
+// StatefulCallback the callback function for stateful operator, called with the state of the signal key.
+// With a store persisting the states as json (e.g. FileStateStore), the type of a value may change once
+// reloaded: numbers become float64, structs and maps become map[string]interface{}
+type StatefulCallback func(s Signal, state *State) (Signal, error)
+
+// State the handle of the state of a key, the changes are saved in the store
+// when the callback returns without error
+type State struct {
+	key     string
+	value   interface{}
+	existed bool
+	changed bool
+	deleted bool
+}
+
+// Key get the key of the state
+func (state *State) Key() string {
+	return state.key
+}
+
+// Value get the value of the state, nil if the state doesn't exist
+func (state *State) Value() interface{} {
+	return state.value
+}
+
+// Exists check if the state exists
+func (state *State) Exists() bool {
+	return state.existed
+}
+
+// GetValue convert the value of the state to a payload struct
+func (state *State) GetValue(value SignalPayload) (existed bool, err error) {
+	if !state.existed {
+		return false, nil
+	}
+	return true, value.Convert(state.value)
+}
+
+// Set set the value of the state
+func (state *State) Set(value interface{}) {
+	state.value = value
+	state.existed = true
+	state.changed = true
+	state.deleted = false
+}
+
+// Delete delete the state
+func (state *State) Delete() {
+	state.value = nil
+	state.existed = false
+	state.changed = false
+	state.deleted = true
+}
+
+/**
+ * Signal Processor for stateful operator
+ */
+
+type keyLock struct {
+	sync.Mutex
+	users int
+}
+
+type statefulProcessor struct {
+	sync.Mutex
+	key     KeyFunc
+	store   StateStore
+	process StatefulCallback
+	locks   map[string]*keyLock
+}
+
+func newStatefulProcessor(key KeyFunc, store StateStore, process StatefulCallback) *statefulProcessor {
+	if key == nil {
+		// all the signals share the same state
+		key = func(s Signal) string {
+			return ""
+		}
+	}
+	if store == nil {
+		store = CreateMemoryStateStore()
+	}
+	return &statefulProcessor{
+		key:     key,
+		store:   store,
+		process: process,
+		locks:   map[string]*keyLock{},
+	}
+}
+
+func (processor *statefulProcessor) OnError(s Signal, send SendSignalFunc) error {
+	send(s)
+	return nil
+}
+
+func (processor *statefulProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	newSignal, err := processor.update(s)
+	if err != nil {
+		return err
+	}
+
+	// the key is unlocked before sending, the downstream nodes don't hold the key
+	send(newSignal)
+	return nil
+}
+
+// update call the callback with the state of the signal key and save the changes
+func (processor *statefulProcessor) update(s Signal) (Signal, error) {
+	key := processor.key(s)
+
+	// the signals of a key are processed one at a time
+	processor.lockKey(key)
+	defer processor.unlockKey(key)
+
+	value, existed, err := processor.store.Get(key)
+	if err != nil {
+		return s, err
+	}
+
+	state := &State{
+		key:     key,
+		value:   value,
+		existed: existed,
+	}
+
+	newSignal, err := processor.process(s, state)
+	if err != nil {
+		return s, err
+	}
+
+	if state.deleted {
+		err = processor.store.Delete(key)
+	} else if state.changed {
+		err = processor.store.Set(key, state.value)
+	}
+	if err != nil {
+		return s, err
+	}
+
+	return newSignal, nil
+}
+
+func (processor *statefulProcessor) lockKey(key string) {
+	processor.Lock()
+	lock, ok := processor.locks[key]
+	if !ok {
+		lock = &keyLock{}
+		processor.locks[key] = lock
+	}
+	lock.users++
+	processor.Unlock()
+
+	lock.Lock()
+}
+
+func (processor *statefulProcessor) unlockKey(key string) {
+	processor.Lock()
+	lock := processor.locks[key]
+	lock.users--
+	if lock.users == 0 {
+		delete(processor.locks, key)
+	}
+	processor.Unlock()
+
+	lock.Unlock()
+}
+
+// Stateful the stateful operator
+type Stateful struct {
+	Node
+}
+
+// Store get the state store of the operator
+func (stateful Stateful) Store() StateStore {
+	return stateful.SignalProcessor().(*statefulProcessor).store
+}
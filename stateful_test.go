@@ -0,0 +1,87 @@
+package collargo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStateful(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+
+	counter := input.Stateful("count per user", KeyByPayload("user"), nil, func(s Signal, state *State) (Signal, error) {
+		count := new(IntPayload)
+		state.GetValue(count)
+		state.Set(count.Value + 1)
+		return s.Set("count", count.Value+1), nil
+	})
+	assert.Equal(t, "stateful", counter.Type())
+	sent := collectSignals(counter)
+
+	// signals of the same key are processed concurrently by the default executor
+	for i := 0; i < 50; i++ {
+		input.Push(map[string]interface{}{"user": "alice"})
+		input.Push(map[string]interface{}{"user": "bob"})
+	}
+	input.Push(map[string]interface{}{"user": "alice"})
+
+	waitSignals(t, sent, 101)
+
+	snapshot, err := counter.Store().Snapshot()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"alice": 51, "bob": 50}, snapshot)
+}
+
+func TestStatefulError(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	store := CreateMemoryStateStore()
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	stateful := ns.Stateful("stateful", KeyByPayload("key"), store, func(s Signal, state *State) (Signal, error) {
+		v, _ := s.Get("value")
+		if v == nil {
+			state.Delete()
+			return s, nil
+		}
+		state.Set(v)
+		if v == "bad" {
+			return s, errors.New("bad value")
+		}
+		return s, nil
+	})
+
+	stateful.Push(map[string]interface{}{"key": "k", "value": "good"})
+	stateful.Push(map[string]interface{}{"key": "k", "value": "bad"})
+
+	// the state is not saved when the callback fails
+	v, existed, _ := store.Get("k")
+	assert.True(t, existed)
+	assert.Equal(t, "good", v)
+
+	stateful.Push(map[string]interface{}{"key": "k"})
+	_, existed, _ = store.Get("k")
+	assert.False(t, existed)
+}
+
+func TestStatefulSharedState(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	counter := ns.Stateful("count all", nil, nil, func(s Signal, state *State) (Signal, error) {
+		count := new(IntPayload)
+		state.GetValue(count)
+		state.Set(count.Value + 1)
+		return s.New(count.Value + 1), nil
+	})
+
+	// the signals sent back to the operator don't wait for the key held by the previous signal
+	counter.When("less than 3", func(s Signal) (bool, error) {
+		v, _ := s.Get(AnonPayload)
+		return v.(int) < 3, nil
+	}).To("loop", counter)
+
+	sent := collectSignals(counter)
+	counter.Push("a")
+	assert.Equal(t, []interface{}{1, 2, 3}, payloadValues(sent(), AnonPayload))
+}
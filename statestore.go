@@ -0,0 +1,284 @@
+package collargo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// StateStore the storage of the keyed states of stateful operators
+type StateStore interface {
+	// Get the value of a key
+	Get(key string) (value interface{}, existed bool, err error)
+	// Set the value of a key
+	Set(key string, value interface{}) error
+	// Delete a key
+	Delete(key string) error
+	// Snapshot get a copy of all the key - value pairs
+	Snapshot() (map[string]interface{}, error)
+	// Restore replace all the key - value pairs with a snapshot
+	Restore(snapshot map[string]interface{}) error
+}
+
+/**
+ * In memory state store
+ */
+
+// MemoryStateStore the state store keeping the states in memory
+type MemoryStateStore struct {
+	sync.RWMutex
+	states map[string]interface{}
+}
+
+// CreateMemoryStateStore create an in memory state store
+func CreateMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		states: map[string]interface{}{},
+	}
+}
+
+// Get get the value of a key
+func (store *MemoryStateStore) Get(key string) (interface{}, bool, error) {
+	store.RLock()
+	defer store.RUnlock()
+	value, existed := store.states[key]
+	return value, existed, nil
+}
+
+// Set set the value of a key
+func (store *MemoryStateStore) Set(key string, value interface{}) error {
+	store.Lock()
+	defer store.Unlock()
+	store.states[key] = value
+	return nil
+}
+
+// Delete delete a key
+func (store *MemoryStateStore) Delete(key string) error {
+	store.Lock()
+	defer store.Unlock()
+	delete(store.states, key)
+	return nil
+}
+
+// Snapshot get a copy of all the states
+func (store *MemoryStateStore) Snapshot() (map[string]interface{}, error) {
+	store.RLock()
+	defer store.RUnlock()
+	snapshot := make(map[string]interface{}, len(store.states))
+	for k, v := range store.states {
+		snapshot[k] = v
+	}
+	return snapshot, nil
+}
+
+// Restore replace all the states with a snapshot
+func (store *MemoryStateStore) Restore(snapshot map[string]interface{}) error {
+	states := make(map[string]interface{}, len(snapshot))
+	for k, v := range snapshot {
+		states[k] = v
+	}
+	store.Lock()
+	store.states = states
+	store.Unlock()
+	return nil
+}
+
+/**
+ * File state store
+ */
+
+// FileStateStore the state store persisting the states in a json lines file, each change is appended to it,
+// the file is compacted when it holds many outdated changes.
+// The values are json encoded: once reloaded, numbers are float64 and structs are maps
+type FileStateStore struct {
+	memory *MemoryStateStore
+	lock   sync.Mutex
+	path   string
+	// the number of changes in the file, including the outdated ones
+	logged int
+}
+
+// stateChange a change of the state of a key, appended to the file
+type stateChange struct {
+	Key     string      `json:"key"`
+	Value   interface{} `json:"value,omitempty"`
+	Deleted bool        `json:"deleted,omitempty"`
+}
+
+// stateCompactThreshold the min number of changes appended to the file before it is compacted
+const stateCompactThreshold = 1000
+
+// CreateFileStateStore create a state store persisted in a json lines file, loading the states it contains
+func CreateFileStateStore(path string) (*FileStateStore, error) {
+	store := &FileStateStore{
+		memory: CreateMemoryStateStore(),
+		path:   path,
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// the last change of a key is the current one
+	states := map[string]interface{}{}
+	decoder := json.NewDecoder(file)
+	for {
+		change := stateChange{}
+		err = decoder.Decode(&change)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		store.logged++
+		if change.Deleted {
+			delete(states, change.Key)
+		} else {
+			states[change.Key] = change.Value
+		}
+	}
+
+	store.memory.Restore(states)
+
+	return store, nil
+}
+
+// Get get the value of a key
+func (store *FileStateStore) Get(key string) (interface{}, bool, error) {
+	return store.memory.Get(key)
+}
+
+// Set set the value of a key and persist it, the state is only changed if it is persisted.
+// Setting the value a key already has is not persisted again
+func (store *FileStateStore) Set(key string, value interface{}) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	current, existed, _ := store.memory.Get(key)
+	if existed && sameJSON(current, value) {
+		return nil
+	}
+
+	err := store.append(stateChange{Key: key, Value: value})
+	if err != nil {
+		return err
+	}
+	return store.memory.Set(key, value)
+}
+
+// Delete delete a key and persist the deletion, the state is only deleted if it is persisted
+func (store *FileStateStore) Delete(key string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	_, existed, _ := store.memory.Get(key)
+	if !existed {
+		return nil
+	}
+
+	err := store.append(stateChange{Key: key, Deleted: true})
+	if err != nil {
+		return err
+	}
+	return store.memory.Delete(key)
+}
+
+// Snapshot get a copy of all the states
+func (store *FileStateStore) Snapshot() (map[string]interface{}, error) {
+	return store.memory.Snapshot()
+}
+
+// Restore replace all the states with a snapshot and persist them, the states are only replaced if persisted
+func (store *FileStateStore) Restore(snapshot map[string]interface{}) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	err := store.save(snapshot)
+	if err != nil {
+		return err
+	}
+	return store.memory.Restore(snapshot)
+}
+
+// append append a change to the file, the file is rewritten instead when it holds many outdated changes,
+// must be called with the lock held
+func (store *FileStateStore) append(change stateChange) error {
+	states, _ := store.memory.Snapshot()
+	if store.logged >= stateCompactThreshold && store.logged >= 2*len(states) {
+		if change.Deleted {
+			delete(states, change.Key)
+		} else {
+			states[change.Key] = change.Value
+		}
+		return store.save(states)
+	}
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(store.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	store.logged++
+	return nil
+}
+
+// save rewrite the file with the states, must be called with the lock held
+func (store *FileStateStore) save(states map[string]interface{}) error {
+	data := []byte{}
+	for key, value := range states {
+		line, err := json.Marshal(stateChange{Key: key, Value: value})
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	tmp := store.path + ".tmp"
+	err := ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp, store.path)
+	if err != nil {
+		return err
+	}
+
+	store.logged = len(states)
+	return nil
+}
+
+// sameJSON check if two values have the same json encoding, e.g. an int and the float64 it is reloaded as
+func sameJSON(a interface{}, b interface{}) bool {
+	dataA, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	dataB, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(dataA, dataB)
+}
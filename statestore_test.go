@@ -0,0 +1,111 @@
+package collargo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemoryStateStore(t *testing.T) {
+	store := CreateMemoryStateStore()
+
+	store.Set("a", 1)
+	store.Set("b", 2)
+	v, existed, err := store.Get("a")
+	assert.Nil(t, err)
+	assert.True(t, existed)
+	assert.Equal(t, 1, v)
+
+	snapshot, _ := store.Snapshot()
+	store.Delete("a")
+	_, existed, _ = store.Get("a")
+	assert.False(t, existed)
+
+	store.Restore(snapshot)
+	v, _, _ = store.Get("a")
+	assert.Equal(t, 1, v)
+
+	// the snapshot is a copy
+	snapshot["c"] = 3
+	_, existed, _ = store.Get("c")
+	assert.False(t, existed)
+}
+
+func TestFileStateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collargo")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state.json")
+
+	store, err := CreateFileStateStore(path)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Set("a", 1))
+	assert.Nil(t, store.Set("b", "text"))
+	assert.Nil(t, store.Delete("b"))
+
+	reloaded, err := CreateFileStateStore(path)
+	assert.Nil(t, err)
+
+	snapshot, _ := reloaded.Snapshot()
+	assert.Equal(t, map[string]interface{}{"a": float64(1)}, snapshot)
+
+	assert.Nil(t, reloaded.Restore(map[string]interface{}{"c": true}))
+
+	reloaded, err = CreateFileStateStore(path)
+	assert.Nil(t, err)
+	v, existed, _ := reloaded.Get("c")
+	assert.True(t, existed)
+	assert.Equal(t, true, v)
+
+	// the state is not changed when it can't be persisted
+	assert.Nil(t, os.RemoveAll(dir))
+	assert.NotNil(t, reloaded.Set("d", 1))
+	_, existed, _ = reloaded.Get("d")
+	assert.False(t, existed)
+	assert.NotNil(t, reloaded.Delete("c"))
+	_, existed, _ = reloaded.Get("c")
+	assert.True(t, existed)
+
+	assert.Nil(t, os.MkdirAll(dir, 0755))
+	ioutil.WriteFile(path, []byte("not json"), 0644)
+	_, err = CreateFileStateStore(path)
+	assert.NotNil(t, err)
+}
+
+func TestFileStateStoreAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collargo")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state.json")
+
+	store, err := CreateFileStateStore(path)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set("a", 1))
+	assert.Nil(t, store.Set("b", 2))
+	info, _ := os.Stat(path)
+	size := info.Size()
+
+	// the unchanged values are not written again, even once reloaded as float64
+	store, err = CreateFileStateStore(path)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Set("a", 1))
+	assert.Nil(t, store.Delete("c"))
+	info, _ = os.Stat(path)
+	assert.Equal(t, size, info.Size())
+
+	// the file is compacted when it holds many outdated changes
+	for i := 0; i < stateCompactThreshold; i++ {
+		assert.Nil(t, store.Set("a", i))
+	}
+	assert.True(t, store.logged < stateCompactThreshold)
+
+	store, err = CreateFileStateStore(path)
+	assert.Nil(t, err)
+	snapshot, _ := store.Snapshot()
+	assert.Equal(t, map[string]interface{}{"a": float64(stateCompactThreshold - 1), "b": float64(2)}, snapshot)
+}
@@ -0,0 +1,83 @@
+package collargo
+
+// SubflowTagPrefix the prefix of the tag marking the signals going through a subflow, followed by the subflow id
+const SubflowTagPrefix string = "__subflow__."
+
+/**
+ * Signal Processor for subflow operator
+ */
+
+type subflowProcessor struct {
+	tag    string
+	input  Node
+	output Node
+}
+
+func (processor *subflowProcessor) OnError(s Signal, send SendSignalFunc) error {
+	processor.input.Push(s.SetTag(processor.tag, "").SetError(s.Error))
+	return nil
+}
+
+func (processor *subflowProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	processor.input.Push(s.SetTag(processor.tag, ""))
+	return nil
+}
+
+// Subflow the subflow operator, a composite node packaging the subgraph between an input and an output:
+// the received signals and errors are pushed to the input, and the ones reaching the output are sent downstream
+type Subflow struct {
+	Node
+	input  Node
+	output Node
+}
+
+// createSubflow create the composite node and forward the signals reaching the output
+func createSubflow(comment string, namespace string, input Node, output Node) Subflow {
+	processor := &subflowProcessor{
+		input:  input,
+		output: output,
+	}
+	subflowNode := CreateNode(comment, namespace, processor)
+	subflowNode.SetType("subflow")
+	subflowNode.AddMeta("input", input.ID())
+	subflowNode.AddMeta("output", output.ID())
+
+	// each subflow only forwards its own signals, the same subgraph can be shared by several subflows
+	processor.tag = SubflowTagPrefix + subflowNode.ID()
+
+	output.Observe(func(node Node, when string, s Signal, data ...interface{}) error {
+		if when != "send" {
+			return nil
+		}
+		if _, ok := s.GetTag(processor.tag); !ok {
+			return nil
+		}
+		subflowNode.Send(s.DelTag(processor.tag).SetError(s.Error))
+		return nil
+	})
+
+	return Subflow{
+		Node:   subflowNode,
+		input:  input,
+		output: output,
+	}
+}
+
+// InputNode get the input of the subgraph
+func (subflow Subflow) InputNode() Node {
+	return subflow.input
+}
+
+// OutputNode get the output of the subgraph
+func (subflow Subflow) OutputNode() Node {
+	return subflow.output
+}
+
+// subflowInput get the input of the subgraph if the node is a subflow
+func subflowInput(n Node) (Node, bool) {
+	processor, ok := n.SignalProcessor().(*subflowProcessor)
+	if !ok {
+		return nil, false
+	}
+	return processor.input, true
+}
@@ -0,0 +1,98 @@
+package collargo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// createDoubleSubflow create an input -> x2 -> output subgraph
+func createDoubleSubflow(ns Namespace) (Input, Output) {
+	input := ns.Input("subflow input")
+	output := ns.Output("subflow output")
+	input.Map("x2", func(s Signal) (Signal, error) {
+		v, _ := s.Get(AnonPayload)
+		if v.(int) < 0 {
+			return s, errors.New("negative")
+		}
+		return s.New(v.(int) * 2), nil
+	}).To("output", output)
+	return input, output
+}
+
+func TestSubflow(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input, output := createDoubleSubflow(ns)
+
+	source := ns.Input("source")
+	double := source.Subflow("double", input, output)
+	assert.Equal(t, "subflow", double.Type())
+	assert.Equal(t, input.ID(), double.InputNode().ID())
+	assert.Equal(t, output.ID(), double.OutputNode().ID())
+
+	plus := double.Map("+1", func(s Signal) (Signal, error) {
+		v, _ := s.Get(AnonPayload)
+		return s.New(v.(int) + 1), nil
+	})
+	sent := collectSignals(plus)
+
+	source.Push(1)
+	source.Push(-1)
+	source.Push(CreateSignal(0).SetError(errors.New("upstream")))
+
+	signals := sent()
+	assert.Equal(t, 3, len(signals))
+	assert.Equal(t, []interface{}{3}, payloadValues(signals[:1], AnonPayload))
+	assert.Equal(t, "negative", signals[1].Error.Error())
+	assert.Equal(t, "upstream", signals[2].Error.Error())
+	for _, s := range signals {
+		assert.Equal(t, 0, len(s.Tags))
+	}
+}
+
+func TestSubflowInstances(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input, output := createDoubleSubflow(ns)
+
+	first := ns.Subflow("first", input, output)
+	second := ns.Subflow("second", input, output)
+	firstSent := collectSignals(first)
+	secondSent := collectSignals(second)
+
+	first.Push(1)
+	second.Push(2)
+	input.Push(3)
+
+	assert.Equal(t, []interface{}{2}, payloadValues(firstSent(), AnonPayload))
+	assert.Equal(t, []interface{}{4}, payloadValues(secondSent(), AnonPayload))
+}
+
+func TestExportTopologySubflow(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input, output := createDoubleSubflow(ns)
+
+	source := ns.Input("source")
+	double := source.Subflow("double", input, output)
+	double.To("sink", ns.Output("sink"))
+
+	collapsed := ExportTopology(source)
+	assert.Equal(t, 3, len(collapsed.Nodes))
+	assert.Equal(t, 2, len(collapsed.Edges))
+
+	expanded := ExportTopologyWithOptions(TopologyOptions{Expand: true}, source)
+	assert.Equal(t, 6, len(expanded.Nodes))
+	assert.Equal(t, 4, len(expanded.Edges))
+
+	parents := map[string]string{}
+	for _, n := range expanded.Nodes {
+		parents[n.ID] = n.Parent
+	}
+	assert.Equal(t, "", parents[source.ID()])
+	assert.Equal(t, "", parents[double.ID()])
+	assert.Equal(t, double.ID(), parents[input.ID()])
+	assert.Equal(t, double.ID(), parents[output.ID()])
+}
@@ -0,0 +1,119 @@
+package collargo
+
+// SwitchTag the tag holding the name of the branch a signal is routed to
+const SwitchTag string = "__switch__"
+
+// DefaultBranch the name of the branch receiving the signals matching no case, and the error signals
+const DefaultBranch string = "default"
+
+// Case a case of the switch operator: the signals accepted by When are routed to the branch Name
+type Case struct {
+	Name string
+	When FilterCallback
+}
+
+/**
+ * Signal Processor for switch operator
+ */
+
+type switchProcessor struct {
+	cases []Case
+}
+
+func (processor switchProcessor) OnError(s Signal, send SendSignalFunc) error {
+	send(s.SetTag(SwitchTag, DefaultBranch).SetError(s.Error))
+	return nil
+}
+
+func (processor switchProcessor) OnSignal(s Signal, send SendSignalFunc) error {
+	for _, c := range processor.cases {
+		match, err := c.When(s)
+		if err != nil {
+			send(s.SetTag(SwitchTag, DefaultBranch).SetError(err))
+			return nil
+		}
+		if match {
+			send(s.SetTag(SwitchTag, c.Name))
+			return nil
+		}
+	}
+
+	send(s.SetTag(SwitchTag, DefaultBranch))
+	return nil
+}
+
+// Switch the switch operator, routing each signal to the branch of the first matching case
+type Switch struct {
+	Node
+	branches map[string]Node
+}
+
+// createSwitch create the switch node and its branches, panics if a case name is duplicated or is DefaultBranch
+func createSwitch(comment string, namespace string, cases []Case) Switch {
+	names := []string{}
+	for _, c := range cases {
+		if c.Name == DefaultBranch {
+			panic("switch case name " + DefaultBranch + " is reserved for the default branch")
+		}
+		for _, name := range names {
+			if name == c.Name {
+				panic("switch has more than one case named " + c.Name)
+			}
+		}
+		names = append(names, c.Name)
+	}
+	names = append(names, DefaultBranch)
+
+	switchNode := CreateNode(comment, namespace, switchProcessor{
+		cases: cases,
+	})
+
+	switchNode.SetType("switch")
+
+	sw := Switch{
+		Node:     switchNode,
+		branches: map[string]Node{},
+	}
+
+	for _, name := range names {
+		branch := CreateNode("", namespace, endpointProcessor{})
+		// the case name is not parsed as a comment, it may contain @ and #
+		concreteNode(branch).name = name
+		concreteNode(branch).comment = name
+		branch.SetType("switch.branch")
+		branch.AddMeta("case", name)
+
+		sw.branches[name] = branch
+		switchNode.Connect(branch, branchEdgeOptions(name))
+	}
+
+	return sw
+}
+
+// branchEdgeOptions get the options of the edge to a branch, only the signals routed to the branch go through it
+func branchEdgeOptions(name string) EdgeOptions {
+	return EdgeOptions{
+		Label: name,
+		Filter: func(s Signal) bool {
+			branch, _ := s.GetTag(SwitchTag)
+			return branch == name
+		},
+		Transform: func(s Signal) Signal {
+			return s.DelTag(SwitchTag).SetError(s.Error)
+		},
+	}
+}
+
+// Case get the branch of a case, to connect the nodes handling the signals matching it
+func (sw Switch) Case(name string) Node {
+	branch, ok := sw.branches[name]
+	if !ok {
+		panic("switch has no case named " + name)
+	}
+	return branch
+}
+
+// Default get the default branch
+func (sw Switch) Default() Node {
+	return sw.branches[DefaultBranch]
+}
@@ -0,0 +1,107 @@
+package collargo
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSwitch(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+
+	sw := input.Switch("route by value",
+		Case{Name: "small", When: func(s Signal) (bool, error) {
+			value, _ := s.Get(AnonPayload)
+			return value.(int) < 10, nil
+		}},
+		Case{Name: "even", When: func(s Signal) (bool, error) {
+			value, _ := s.Get(AnonPayload)
+			return value.(int)%2 == 0, nil
+		}},
+	)
+	assert.Equal(t, "switch", sw.Type())
+	assert.Equal(t, 3, len(sw.Downstreams()))
+
+	small := collectSignals(sw.Case("small"))
+	even := collectSignals(sw.Case("even"))
+	others := collectSignals(sw.Default())
+
+	input.Push(2)
+	input.Push(12)
+	input.Push(13)
+	input.Push(CreateSignal(0).SetError(errors.New("error")))
+
+	assert.Equal(t, []interface{}{2}, payloadValues(small(), AnonPayload))
+	assert.Equal(t, []interface{}{12}, payloadValues(even(), AnonPayload))
+	assert.Equal(t, []interface{}{13}, payloadValues(others()[:1], AnonPayload))
+	assert.Equal(t, 2, len(others()))
+	assert.Equal(t, "error", others()[1].Error.Error())
+
+	_, tagged := small()[0].GetTag(SwitchTag)
+	assert.False(t, tagged)
+
+	assert.Panics(t, func() {
+		sw.Case("unknown")
+	})
+}
+
+func TestSwitchCaseError(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	sw := ns.Route("route", Case{Name: "fail", When: func(s Signal) (bool, error) {
+		return false, errors.New("cannot evaluate")
+	}})
+
+	failed := collectSignals(sw.Case("fail"))
+	others := collectSignals(sw.Default())
+
+	sw.Push(1)
+
+	assert.Equal(t, 0, len(failed()))
+	assert.Equal(t, 1, len(others()))
+	assert.Equal(t, "cannot evaluate", others()[0].Error.Error())
+}
+
+func TestSwitchBranchReceive(t *testing.T) {
+	defer useExecutor(CreateSyncExecutor())()
+
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	sw := ns.Switch("route", Case{Name: "@odd #1", When: func(s Signal) (bool, error) {
+		value, _ := s.Get(AnonPayload)
+		return value.(int)%2 == 1, nil
+	}})
+
+	odd := sw.Case("@odd #1")
+	assert.Equal(t, "@odd #1", odd.Name())
+	assert.Equal(t, 0, len(odd.Tags()))
+
+	// the branches not matching don't receive the signal
+	received := 0
+	odd.Observe(func(node Node, when string, s Signal, data ...interface{}) error {
+		if when == "onReceive" {
+			received++
+		}
+		return nil
+	})
+
+	sw.Push(2)
+	assert.Equal(t, 0, received)
+	sw.Push(3)
+	assert.Equal(t, 1, received)
+}
+
+func TestSwitchInvalidCases(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("input")
+	always := func(s Signal) (bool, error) {
+		return true, nil
+	}
+
+	assert.Panics(t, func() { ns.Switch("route", Case{Name: "a", When: always}, Case{Name: "a", When: always}) })
+	assert.Panics(t, func() { input.Switch("route", Case{Name: DefaultBranch, When: always}) })
+	assert.Equal(t, 0, len(input.Downstreams()))
+}
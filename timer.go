@@ -0,0 +1,212 @@
+package collargo
+
+import (
+	"context"
+	"errors"
+	"github.com/robfig/cron"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MissedTickPolicy what a timer sensor does when ticks are missed, when a tick fires after the following ones
+// were scheduled (slow downstream with a synchronous executor, suspended process, ...)
+type MissedTickPolicy int
+
+const (
+	// MissedTickFireOnce send one signal for the late tick, its "missed" payload is the number of missed ticks
+	MissedTickFireOnce MissedTickPolicy = iota
+	// MissedTickCatchUp send one signal for the late tick and one for each missed tick
+	MissedTickCatchUp
+	// MissedTickSkip send no signal for a late tick, wait for the next tick on schedule
+	MissedTickSkip
+)
+
+// intervalSchedule the schedule of the interval sensor
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func (schedule intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(schedule.interval)
+}
+
+// TimerSensor the timer sensor, sending a tick signal at each scheduled time once started:
+// the payload has the tick number ("tick"), the scheduled time ("scheduled"), the time it fired ("fired")
+// and the number of missed ticks ("missed"), the "schedule" tag is the interval or the cron spec
+type TimerSensor struct {
+	Sensor
+	lock     sync.Mutex
+	spec     string
+	schedule cron.Schedule
+	jitter   time.Duration
+	policy   MissedTickPolicy
+	tick     int
+}
+
+// createTimerSensor create a timer sensor with a schedule
+func createTimerSensor(ns Namespace, comment string, spec string, schedule cron.Schedule) *TimerSensor {
+	timer := &TimerSensor{
+		spec:     spec,
+		schedule: schedule,
+		policy:   MissedTickFireOnce,
+	}
+
+	timer.Sensor = ns.Sensor(comment, timer.watch, true)
+	timer.SetType("sensor.timer")
+	timer.AddMeta("schedule", spec)
+
+	return timer
+}
+
+// createIntervalSensor create a timer sensor ticking at each interval, returns an error if the interval is not positive
+func createIntervalSensor(ns Namespace, comment string, interval time.Duration) (*TimerSensor, error) {
+	if interval <= 0 {
+		return nil, errors.New("Interval expects a positive interval, got " + interval.String())
+	}
+	return createTimerSensor(ns, comment, "@every "+interval.String(), intervalSchedule{
+		interval: interval,
+	}), nil
+}
+
+// parseSchedule parse the spec of a timer sensor, an interval ("@every 20ms") or a cron spec
+func parseSchedule(spec string) (cron.Schedule, error) {
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimPrefix(spec, "@every "))
+		if err != nil {
+			return nil, err
+		}
+		if interval <= 0 {
+			return nil, errors.New("interval of " + spec + " is not positive")
+		}
+		return intervalSchedule{
+			interval: interval,
+		}, nil
+	}
+	return parseCronSpec(spec)
+}
+
+// parseCronSpec parse a standard cron spec, or a spec with a seconds field if it has 6 fields
+func parseCronSpec(spec string) (cron.Schedule, error) {
+	if len(strings.Fields(spec)) == 6 {
+		return cron.Parse(spec)
+	}
+	return cron.ParseStandard(spec)
+}
+
+// SetJitter delay each tick by a random duration up to jitter, the schedule is not shifted
+func (timer *TimerSensor) SetJitter(jitter time.Duration) *TimerSensor {
+	timer.lock.Lock()
+	timer.jitter = jitter
+	timer.lock.Unlock()
+	return timer
+}
+
+// SetMissedTickPolicy set the missed tick policy, MissedTickFireOnce by default
+func (timer *TimerSensor) SetMissedTickPolicy(policy MissedTickPolicy) *TimerSensor {
+	timer.lock.Lock()
+	timer.policy = policy
+	timer.lock.Unlock()
+	return timer
+}
+
+// Start start to send the ticks
+func (timer *TimerSensor) Start() *TimerSensor {
+	timer.Watch(SensorOptions{"schedule": timer.spec})
+	return timer
+}
+
+// watch send the ticks of the schedule of the options until the sensor is stopped
+func (timer *TimerSensor) watch(ctx context.Context, options SensorOptions, send SendDataFunc) error {
+	spec, ok := options["schedule"].(string)
+	if !ok {
+		spec = timer.spec
+	}
+
+	schedule := timer.schedule
+	if spec != timer.spec {
+		var err error
+		if schedule, err = parseSchedule(spec); err != nil {
+			send(err)
+			return err
+		}
+	}
+
+	next := schedule.Next(time.Now())
+
+	for {
+		timer.lock.Lock()
+		jitter := timer.jitter
+		timer.lock.Unlock()
+
+		wait := time.Until(next)
+		if jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(jitter)))
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+
+		fired := time.Now()
+		missed := missedTicks(schedule, next, fired)
+
+		for _, tick := range timer.ticks(spec, next, missed, fired) {
+			send(tick)
+		}
+
+		next = schedule.Next(next)
+		if len(missed) > 0 {
+			next = schedule.Next(missed[len(missed)-1])
+		}
+	}
+}
+
+// ticks get the signals of the tick of spec scheduled at scheduled, fired with missed ticks, following the policy
+func (timer *TimerSensor) ticks(spec string, scheduled time.Time, missed []time.Time, fired time.Time) []Signal {
+	timer.lock.Lock()
+	defer timer.lock.Unlock()
+
+	scheduledTicks := []time.Time{scheduled}
+	switch {
+	case len(missed) > 0 && timer.policy == MissedTickSkip:
+		return []Signal{}
+	case timer.policy == MissedTickCatchUp:
+		scheduledTicks = append(scheduledTicks, missed...)
+	}
+
+	signals := []Signal{}
+	for i, tick := range scheduledTicks {
+		timer.tick++
+
+		count := 0
+		if i == 0 && timer.policy == MissedTickFireOnce {
+			count = len(missed)
+		}
+
+		signals = append(signals, CreateSignal(map[string]interface{}{
+			"tick":      timer.tick,
+			"scheduled": tick,
+			"fired":     fired,
+			"missed":    count,
+		}).SetTag("schedule", spec))
+	}
+	return signals
+}
+
+// missedTicks get the ticks scheduled after the tick at scheduled and before it fired,
+// a schedule not moving forward has no missed ticks
+func missedTicks(schedule cron.Schedule, scheduled time.Time, fired time.Time) []time.Time {
+	missed := []time.Time{}
+	previous := scheduled
+	for next := schedule.Next(scheduled); next.After(previous) && !next.After(fired); next = schedule.Next(next) {
+		missed = append(missed, next)
+		previous = next
+	}
+	return missed
+}
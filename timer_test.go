@@ -0,0 +1,115 @@
+package collargo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestInterval(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	interval, err := ns.Interval("every 20ms", 20*time.Millisecond)
+	assert.Nil(t, err)
+	interval.SetJitter(5 * time.Millisecond)
+	assert.Equal(t, "sensor.timer", interval.Type())
+	sent := collectSignals(interval)
+
+	interval.Start()
+	time.Sleep(110 * time.Millisecond)
+	interval.Stop()
+	interval.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	signals := sent()
+	assert.True(t, len(signals) >= 3)
+	assert.True(t, len(signals) <= 5)
+	for i, s := range signals {
+		tick, _ := s.Get("tick")
+		assert.Equal(t, i+1, tick)
+		scheduled, _ := s.Get("scheduled")
+		fired, _ := s.Get("fired")
+		assert.False(t, fired.(time.Time).Before(scheduled.(time.Time)))
+		schedule, _ := s.GetTag("schedule")
+		assert.Equal(t, "@every 20ms", schedule)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, len(signals), len(sent()))
+}
+
+func TestCron(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+
+	_, err := ns.Cron("invalid", "not a spec")
+	assert.NotNil(t, err)
+
+	hourly, err := ns.Cron("hourly", "0 * * * *")
+	assert.Nil(t, err)
+	schedule, _ := hourly.GetMeta("schedule")
+	assert.Equal(t, "0 * * * *", schedule)
+
+	from := time.Date(2020, 1, 1, 10, 30, 0, 0, time.Local)
+	assert.Equal(t, time.Date(2020, 1, 1, 11, 0, 0, 0, time.Local), hourly.schedule.Next(from))
+
+	everySecond, err := ns.Cron("every second", "* * * * * *")
+	assert.Nil(t, err)
+	assert.Equal(t, from.Add(time.Second), everySecond.schedule.Next(from))
+}
+
+func TestMissedTicks(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	timer, err := ns.Interval("every second", time.Second)
+	assert.Nil(t, err)
+
+	scheduled := time.Date(2020, 1, 1, 10, 0, 0, 0, time.Local)
+	fired := scheduled.Add(2500 * time.Millisecond)
+	missed := missedTicks(timer.schedule, scheduled, fired)
+	assert.Equal(t, []time.Time{scheduled.Add(time.Second), scheduled.Add(2 * time.Second)}, missed)
+	assert.Equal(t, 0, len(missedTicks(timer.schedule, scheduled, scheduled.Add(10*time.Millisecond))))
+
+	signals := timer.ticks(timer.spec, scheduled, missed, fired)
+	assert.Equal(t, 1, len(signals))
+	assert.Equal(t, []interface{}{2}, payloadValues(signals, "missed"))
+
+	timer.SetMissedTickPolicy(MissedTickCatchUp)
+	signals = timer.ticks(timer.spec, scheduled, missed, fired)
+	assert.Equal(t, []interface{}{2, 3, 4}, payloadValues(signals, "tick"))
+	assert.Equal(t, []interface{}{scheduled, scheduled.Add(time.Second), scheduled.Add(2 * time.Second)}, payloadValues(signals, "scheduled"))
+
+	timer.SetMissedTickPolicy(MissedTickSkip)
+	assert.Equal(t, 0, len(timer.ticks(timer.spec, scheduled, missed, fired)))
+	assert.Equal(t, 1, len(timer.ticks(timer.spec, scheduled, []time.Time{}, fired)))
+}
+
+func TestIntervalNotPositive(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	_, err := ns.Interval("never", 0)
+	assert.NotNil(t, err)
+	_, err = ns.Interval("never", -time.Second)
+	assert.NotNil(t, err)
+
+	// a schedule not moving forward doesn't loop
+	scheduled := time.Date(2020, 1, 1, 10, 0, 0, 0, time.Local)
+	missed := missedTicks(intervalSchedule{}, scheduled, scheduled.Add(time.Second))
+	assert.Equal(t, 0, len(missed))
+}
+
+func TestTimerScheduleOption(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	timer, err := ns.Interval("every hour", time.Hour)
+	assert.Nil(t, err)
+	sent := collectSignals(timer)
+
+	// the schedule is the one of the options
+	timer.Watch(SensorOptions{"schedule": "@every 10ms"})
+	signals := waitSignals(t, sent, 1)
+	timer.Stop()
+
+	schedule, _ := signals[0].GetTag("schedule")
+	assert.Equal(t, "@every 10ms", schedule)
+
+	timer.Restart(SensorOptions{"schedule": "not a spec"})
+	time.Sleep(10 * time.Millisecond)
+	assert.NotNil(t, timer.LastError())
+	timer.Stop()
+}
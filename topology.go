@@ -0,0 +1,110 @@
+package collargo
+
+import (
+	"encoding/json"
+)
+
+// TopologyNode a node of an exported topology
+type TopologyNode struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	FullName  string            `json:"fullName"`
+	Namespace string            `json:"namespace"`
+	Type      string            `json:"type"`
+	Comment   string            `json:"comment"`
+	Tags      []string          `json:"tags,omitempty"`
+	Meta      map[string]string `json:"meta"`
+	Parent    string            `json:"parent,omitempty"` // the id of the subflow containing the node, when expanded
+}
+
+// TopologyEdge an edge of an exported topology
+type TopologyEdge struct {
+	ID     string   `json:"id"`
+	Source string   `json:"source"`
+	Target string   `json:"target"`
+	Label  string   `json:"label,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// Topology the nodes and edges of a graph
+type Topology struct {
+	Nodes []TopologyNode `json:"nodes"`
+	Edges []TopologyEdge `json:"edges"`
+}
+
+// TopologyOptions the options of a topology export
+type TopologyOptions struct {
+	// export the subgraphs of the subflows, their nodes have the subflow as parent
+	Expand bool
+}
+
+// topologyItem a node to export, with the subflow containing it
+type topologyItem struct {
+	node   Node
+	parent string
+}
+
+// ExportTopology export the topology of the graph reachable from the root nodes, with collapsed subflows
+func ExportTopology(roots ...Node) Topology {
+	return ExportTopologyWithOptions(TopologyOptions{}, roots...)
+}
+
+// ExportTopologyWithOptions export the topology of the graph reachable from the root nodes
+func ExportTopologyWithOptions(options TopologyOptions, roots ...Node) Topology {
+	topology := Topology{
+		Nodes: []TopologyNode{},
+		Edges: []TopologyEdge{},
+	}
+
+	visited := map[string]bool{}
+	queue := []topologyItem{}
+	for _, root := range roots {
+		queue = append(queue, topologyItem{node: root})
+	}
+
+	for len(queue) > 0 {
+		n := queue[0].node
+		parent := queue[0].parent
+		queue = queue[1:]
+
+		if visited[n.ID()] {
+			continue
+		}
+		visited[n.ID()] = true
+
+		topology.Nodes = append(topology.Nodes, TopologyNode{
+			ID:        n.ID(),
+			Name:      n.Name(),
+			FullName:  n.FullName(),
+			Namespace: n.Namespace(),
+			Type:      n.Type(),
+			Comment:   n.Comment(),
+			Tags:      n.Tags(),
+			Meta:      n.GetAllMeta(),
+			Parent:    parent,
+		})
+
+		if input, ok := subflowInput(n); ok && options.Expand {
+			queue = append(queue, topologyItem{node: input, parent: n.ID()})
+		}
+
+		for _, edge := range n.Edges() {
+			topology.Edges = append(topology.Edges, TopologyEdge{
+				ID:     edge.ID,
+				Source: n.ID(),
+				Target: edge.Target.ID(),
+				Label:  edge.Label,
+				Tags:   edge.Tags,
+			})
+			queue = append(queue, topologyItem{node: edge.Target, parent: parent})
+		}
+	}
+
+	return topology
+}
+
+// ToJSON serialize the topology as a json string
+func (topology Topology) ToJSON() (string, error) {
+	jsonByte, err := json.Marshal(topology)
+	return string(jsonByte), err
+}
@@ -0,0 +1,36 @@
+package collargo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExportTopology(t *testing.T) {
+	ns := Collar.NS("com.collargo.test", map[string]string{})
+	input := ns.Input("@topology input")
+	sw := input.Switch("route", Case{Name: "yes", When: func(s Signal) (bool, error) {
+		return true, nil
+	}})
+	sw.Case("yes").Map("yes", func(s Signal) (Signal, error) {
+		return s, nil
+	})
+
+	topology := ExportTopology(input)
+	assert.Equal(t, 5, len(topology.Nodes))
+	assert.Equal(t, input.ID(), topology.Nodes[0].ID)
+	assert.Equal(t, "topology", topology.Nodes[0].Name)
+	assert.Equal(t, 4, len(topology.Edges))
+
+	labels := map[string]string{}
+	for _, edge := range topology.Edges {
+		if edge.Source == sw.ID() {
+			labels[edge.Target] = edge.Label
+		}
+	}
+	assert.Equal(t, "yes", labels[sw.Case("yes").ID()])
+	assert.Equal(t, DefaultBranch, labels[sw.Default().ID()])
+
+	json, err := topology.ToJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, json, `"label":"yes"`)
+}
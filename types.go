@@ -1,5 +1,9 @@
 package collargo
 
+import (
+	"context"
+)
+
 /**
  * Public types
  */
@@ -14,6 +18,25 @@ type SendDataFunc func(interface{})
 // FlowFunc the function converted from a flow
 type FlowFunc func(data interface{}) (Payload, error)
 
+// ContextFlowFunc the function converted from a flow, giving up when the context is done
+type ContextFlowFunc func(ctx context.Context, data interface{}) (Payload, error)
+
+// MultiFlowFunc the function converted from a flow with several outputs, returns the name of the output which fired
+type MultiFlowFunc func(data interface{}) (output string, result Payload, err error)
+
+// ContextMultiFlowFunc the function converted from a flow with several outputs, giving up when the context is done
+type ContextMultiFlowFunc func(ctx context.Context, data interface{}) (output string, result Payload, err error)
+
+// FlowResult a result of a streaming flow function
+type FlowResult struct {
+	Payload Payload
+	Err     error
+}
+
+// StreamFlowFunc the function converted from a flow sending several results,
+// the channel is closed after the result of the end signal or when the context is done
+type StreamFlowFunc func(ctx context.Context, data interface{}) <-chan FlowResult
+
 // Callback the callback function
 type Callback func(err error, data Payload)
 
@@ -2,8 +2,10 @@ package collargo
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/websocket"
 	"log"
+	"sync"
 )
 
 type Message struct {
@@ -14,6 +16,9 @@ type Message struct {
 
 type MessageHandler func(interface{}) error
 
+// ErrNotConnected the error returned when emitting a message before connecting to the server
+var ErrNotConnected = errors.New("websocket client is not connected")
+
 // WebsocketClient the client to connect to websocket
 type WebsocketClient struct {
 	url          string
@@ -21,6 +26,7 @@ type WebsocketClient struct {
 	clientSecret string
 	conn         *websocket.Conn
 	handlers     map[string]([]MessageHandler)
+	writeLock    *sync.Mutex
 }
 
 // CreateWebsocketClient Create a websocket client
@@ -30,6 +36,7 @@ func CreateWebsocketClient(url string, clientID string, clientSecret string) Web
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		handlers:     map[string]([]MessageHandler){},
+		writeLock:    &sync.Mutex{},
 	}
 }
 
@@ -104,6 +111,10 @@ func (client *WebsocketClient) On(msg string, handler MessageHandler) {
 // Emit emit a message to server
 func (client *WebsocketClient) Emit(msg string, data interface{}) error {
 	var err error
+	if client.conn == nil {
+		return ErrNotConnected
+	}
+
 	m := Message{
 		Type: msg,
 		ID:   client.clientID,
@@ -119,7 +130,10 @@ func (client *WebsocketClient) Emit(msg string, data interface{}) error {
 	// marshaled, _ := json.MarshalIndent(m, "", " ")
 	// log.Println(string(marshaled))
 
+	// the connection supports only one concurrent writer
+	client.writeLock.Lock()
 	err = client.conn.WriteMessage(websocket.TextMessage, byteMsg)
+	client.writeLock.Unlock()
 	if err != nil {
 		log.Println("Emit:", "error when sending message to collar dev server", err)
 		return err